@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"context"
+	"log"
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// AuthFunc returns headers to attach to an export request. It is invoked for
+// every export, so short-lived credentials such as bearer tokens can be refreshed.
+// An error aborts the export.
+type AuthFunc func(ctx context.Context) (map[string]string, error)
+
+// grpcAuth adapts an AuthFunc to gRPC per-RPC credentials.
+type grpcAuth struct {
+	auth AuthFunc
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials.
+func (g grpcAuth) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	return g.auth(ctx)
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials. Headers are
+// sent over insecure connections as well, matching the behavior of static headers.
+func (g grpcAuth) RequireTransportSecurity() bool {
+	return false
+}
+
+// httpAuthExporter is an OTLP/HTTP exporter that sends the headers returned by
+// an AuthFunc. The OTLP/HTTP exporter only accepts static headers, so before
+// every export the AuthFunc is called and, if its headers changed, the exporter
+// is recreated with them through newExporter.
+type httpAuthExporter struct {
+	auth        AuthFunc
+	newExporter func(headers map[string]string) (sdkmetric.Exporter, error)
+
+	mu       sync.Mutex
+	exp      sdkmetric.Exporter
+	headers  map[string]string // headers of exp; nil before the first export
+	shutdown bool
+}
+
+// newHTTPAuthExporter returns an httpAuthExporter that starts with an exporter
+// without auth headers, which is replaced on the first export.
+func newHTTPAuthExporter(auth AuthFunc, newExporter func(map[string]string) (sdkmetric.Exporter, error)) (*httpAuthExporter, error) {
+	exp, err := newExporter(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &httpAuthExporter{auth: auth, newExporter: newExporter, exp: exp}, nil
+}
+
+// Temporality implements sdkmetric.Exporter.
+func (a *httpAuthExporter) Temporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.exp.Temporality(kind)
+}
+
+// Aggregation implements sdkmetric.Exporter.
+func (a *httpAuthExporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.exp.Aggregation(kind)
+}
+
+// Export implements sdkmetric.Exporter. An error of the AuthFunc aborts the
+// export.
+func (a *httpAuthExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	headers, err := a.auth(ctx)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.shutdown && (a.headers == nil || !maps.Equal(a.headers, headers)) {
+		exp, err := a.newExporter(headers)
+		if err != nil {
+			return err
+		}
+		if err := a.exp.Shutdown(ctx); err != nil {
+			log.Printf("[metrics] Shutdown of exporter with previous auth headers failed: %v", err)
+		}
+		a.exp, a.headers = exp, maps.Clone(headers)
+		if a.headers == nil {
+			a.headers = map[string]string{}
+		}
+	}
+	return a.exp.Export(ctx, rm)
+}
+
+// ForceFlush implements sdkmetric.Exporter.
+func (a *httpAuthExporter) ForceFlush(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.exp.ForceFlush(ctx)
+}
+
+// Shutdown implements sdkmetric.Exporter.
+func (a *httpAuthExporter) Shutdown(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.shutdown = true
+	return a.exp.Shutdown(ctx)
+}
+
+// redactHeaders renders header names for logging with their values hidden.
+func redactHeaders(headers map[string]string) string {
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k+"=<redacted>")
+	}
+	sort.Strings(keys)
+	return "[" + strings.Join(keys, " ") + "]"
+}
@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestRedactHeaders(t *testing.T) {
+	out := redactHeaders(map[string]string{"x-tenant": "acme", "api-key": "secret"})
+	require.Equal(t, "[api-key=<redacted> x-tenant=<redacted>]", out)
+	require.NotContains(t, out, "secret")
+	require.Equal(t, "[]", redactHeaders(nil))
+}
+
+func TestGRPCAuth(t *testing.T) {
+	calls := 0
+	creds := grpcAuth{auth: func(context.Context) (map[string]string, error) {
+		calls++
+		return map[string]string{"authorization": "Bearer token"}, nil
+	}}
+
+	md, err := creds.GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "Bearer token", md["authorization"])
+	require.False(t, creds.RequireTransportSecurity())
+
+	_, err = creds.GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, calls, "expected the auth hook to run for every request")
+}
+
+func TestHTTPExporter_HeadersAndAuth(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []http.Header
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, r.Header.Clone())
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	// Each export gets a fresh token from the auth hook.
+	var tokens []string
+	auth := func(context.Context) (map[string]string, error) {
+		token := "token-" + string(rune('a'+len(tokens)))
+		tokens = append(tokens, token)
+		return map[string]string{"Authorization": "Bearer " + token}, nil
+	}
+
+	cfg := NewConfig(strings.TrimPrefix(srv.URL, "http://"), "test-service", "test",
+		WithOTLPProtocol(ProtocolHTTPProtobuf),
+		WithOTLPHeaders(map[string]string{"X-Tenant": "acme"}),
+		WithOTLPAuth(auth),
+	)
+	require.NoError(t, validateConfig(cfg))
+
+	ctx := context.Background()
+	exp, err := createOTLPExporter(ctx, cfg)
+	require.NoError(t, err)
+	defer func() { _ = exp.Shutdown(ctx) }()
+
+	require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+	require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received, 2)
+	for i, h := range received {
+		require.Equal(t, "acme", h.Get("X-Tenant"))
+		require.Equal(t, "Bearer "+tokens[i], h.Get("Authorization"))
+	}
+}
+
+func TestHTTPAuthExporter_RecreatesOnHeaderChange(t *testing.T) {
+	token := "a"
+	auth := func(context.Context) (map[string]string, error) {
+		return map[string]string{"Authorization": "Bearer " + token}, nil
+	}
+	var created []map[string]string
+	newExporter := func(headers map[string]string) (sdkmetric.Exporter, error) {
+		created = append(created, headers)
+		return stdoutmetric.New(stdoutmetric.WithWriter(io.Discard))
+	}
+
+	ctx := context.Background()
+	exp, err := newHTTPAuthExporter(auth, newExporter)
+	require.NoError(t, err)
+	defer func() { _ = exp.Shutdown(ctx) }()
+	require.Len(t, created, 1)
+	require.Nil(t, created[0], "expected no auth headers before the first export")
+
+	// The exporter is only recreated when the headers change.
+	require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+	require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+	require.Len(t, created, 2)
+	require.Equal(t, "Bearer a", created[1]["Authorization"])
+
+	token = "b"
+	require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+	require.Len(t, created, 3)
+	require.Equal(t, "Bearer b", created[2]["Authorization"])
+
+	// After shutdown, the exporter is no longer recreated.
+	require.NoError(t, exp.Shutdown(ctx))
+	token = "c"
+	require.Error(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+	require.Len(t, created, 3)
+}
+
+func TestHTTPExporter_AuthError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	cfg := NewConfig(strings.TrimPrefix(srv.URL, "http://"), "test-service", "test",
+		WithOTLPProtocol(ProtocolHTTPProtobuf),
+		WithOTLPAuth(func(context.Context) (map[string]string, error) {
+			return nil, errors.New("token expired")
+		}),
+	)
+
+	ctx := context.Background()
+	exp, err := createOTLPExporter(ctx, cfg)
+	require.NoError(t, err)
+	defer func() { _ = exp.Shutdown(ctx) }()
+
+	err = exp.Export(ctx, &metricdata.ResourceMetrics{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "token expired")
+}
+
+func TestValidateConfig_EmptyHeaderName(t *testing.T) {
+	cfg := NewConfig("localhost:4317", "test-service", "test",
+		WithOTLPHeaders(map[string]string{" ": "value"}),
+	)
+	require.ErrorContains(t, validateConfig(cfg), "empty name")
+}
@@ -0,0 +1,395 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// defaultCgroupRoot is the mount point of the cgroup file systems.
+	defaultCgroupRoot = "/sys/fs/cgroup"
+
+	// procSelfCgroup lists the cgroups of the current process.
+	procSelfCgroup = "/proc/self/cgroup"
+
+	// cgroupV1Unlimited is the smallest cgroup v1 memory limit considered
+	// unlimited; the kernel reports "no limit" as a page-aligned MaxInt64.
+	cgroupV1Unlimited = math.MaxInt64 &^ (1<<16 - 1)
+)
+
+// ContainerOption configures NewContainerMetrics.
+type ContainerOption func(*containerConfig)
+
+// containerConfig holds the settings of ContainerMetrics.
+type containerConfig struct {
+	cgroupRoot string
+}
+
+// WithCgroupRoot sets the directory where the cgroup file systems are mounted.
+// The default is "/sys/fs/cgroup".
+func WithCgroupRoot(dir string) ContainerOption {
+	return func(cfg *containerConfig) {
+		cfg.cgroupRoot = dir
+	}
+}
+
+// ContainerMetrics holds the asynchronous instruments for the resource limits
+// and usage of the cgroup (container) the process runs in, and the Go memory
+// limit that should match them.
+type ContainerMetrics struct {
+	memoryLimit      metric.Int64ObservableGauge
+	memoryUsage      metric.Int64ObservableGauge
+	memoryWorkingSet metric.Int64ObservableGauge
+	cpuQuota         metric.Int64ObservableGauge
+	cpuPeriod        metric.Int64ObservableGauge
+	cpuLimit         metric.Float64ObservableGauge
+	cpuPeriods       metric.Int64ObservableCounter
+	cpuThrottled     metric.Int64ObservableCounter
+	cpuThrottledTime metric.Float64ObservableCounter
+	goMemLimit       metric.Int64ObservableGauge
+
+	// cgroup is nil if no cgroup could be found.
+	cgroup cgroupReader
+}
+
+// NewContainerMetrics creates and registers asynchronous instruments that
+// capture the limits and usage of the cgroup of the process, for cgroup v1 and
+// v2:
+//
+//   - container.memory.limit, container.memory.usage: the memory limit and
+//     current usage, including the page cache.
+//   - container.memory.working_set: the usage minus inactive file pages, which
+//     is what the kernel (and Kubernetes) compare against the limit.
+//   - container.cpu.quota, container.cpu.period: the CFS quota and period in
+//     microseconds; container.cpu.limit: their ratio in CPUs.
+//   - container.cpu.periods, container.cpu.throttled_periods: enforcement
+//     periods elapsed and periods in which the cgroup was throttled;
+//     container.cpu.throttled_time: the total time it was throttled.
+//   - container.go.memlimit: the Go soft memory limit (GOMEMLIMIT), to compare
+//     with the above. GOMAXPROCS is reported by RuntimeMetrics as
+//     go.sched.gomaxprocs.
+//
+// Limits that are not set are not reported. The cgroup is resolved from
+// /proc/self/cgroup below the cgroup root (see WithCgroupRoot), falling back to
+// the root itself, as seen inside a container with its own cgroup namespace.
+// Outside of Linux, only the Go memory limit is reported.
+func NewContainerMetrics(meter metric.Meter, opts ...ContainerOption) (*ContainerMetrics, error) {
+	cfg := containerConfig{cgroupRoot: defaultCgroupRoot}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	cm := &ContainerMetrics{}
+	var err error
+
+	if cm.memoryLimit, err = meter.Int64ObservableGauge("container.memory.limit", metric.WithUnit("By")); err != nil {
+		return nil, err
+	}
+	if cm.memoryUsage, err = meter.Int64ObservableGauge("container.memory.usage", metric.WithUnit("By")); err != nil {
+		return nil, err
+	}
+	if cm.memoryWorkingSet, err = meter.Int64ObservableGauge("container.memory.working_set", metric.WithUnit("By")); err != nil {
+		return nil, err
+	}
+	if cm.cpuQuota, err = meter.Int64ObservableGauge("container.cpu.quota", metric.WithUnit("us")); err != nil {
+		return nil, err
+	}
+	if cm.cpuPeriod, err = meter.Int64ObservableGauge("container.cpu.period", metric.WithUnit("us")); err != nil {
+		return nil, err
+	}
+	if cm.cpuLimit, err = meter.Float64ObservableGauge("container.cpu.limit", metric.WithUnit("{cpu}")); err != nil {
+		return nil, err
+	}
+	if cm.cpuPeriods, err = meter.Int64ObservableCounter("container.cpu.periods"); err != nil {
+		return nil, err
+	}
+	if cm.cpuThrottled, err = meter.Int64ObservableCounter("container.cpu.throttled_periods"); err != nil {
+		return nil, err
+	}
+	if cm.cpuThrottledTime, err = meter.Float64ObservableCounter("container.cpu.throttled_time", metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if cm.goMemLimit, err = meter.Int64ObservableGauge("container.go.memlimit", metric.WithUnit("By")); err != nil {
+		return nil, err
+	}
+
+	cm.cgroup = detectCgroup(cfg.cgroupRoot, procSelfCgroup)
+	if cm.cgroup == nil {
+		log.Printf("[metrics] Container metrics unavailable: no cgroup found in %s", cfg.cgroupRoot)
+	}
+
+	// Register a single callback for all metrics.
+	_, err = meter.RegisterCallback(
+		cm.observe,
+		cm.memoryLimit, cm.memoryUsage, cm.memoryWorkingSet,
+		cm.cpuQuota, cm.cpuPeriod, cm.cpuLimit,
+		cm.cpuPeriods, cm.cpuThrottled, cm.cpuThrottledTime,
+		cm.goMemLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return cm, nil
+}
+
+// observe is called once per collection interval to sample all metrics.
+func (cm *ContainerMetrics) observe(_ context.Context, obs metric.Observer) error {
+	// A negative limit means no limit; SetMemoryLimit(-1) only reads it.
+	if limit := debug.SetMemoryLimit(-1); limit < math.MaxInt64 {
+		obs.ObserveInt64(cm.goMemLimit, limit)
+	}
+
+	if cm.cgroup == nil {
+		return nil
+	}
+
+	mem := cm.cgroup.memory()
+	observeIfSet(obs, cm.memoryLimit, mem.limit)
+	observeIfSet(obs, cm.memoryUsage, mem.usage)
+	if mem.usage >= 0 && mem.inactiveFile >= 0 {
+		obs.ObserveInt64(cm.memoryWorkingSet, max(mem.usage-mem.inactiveFile, 0))
+	}
+
+	cpu := cm.cgroup.cpu()
+	observeIfSet(obs, cm.cpuPeriod, cpu.period)
+	if cpu.quota >= 0 {
+		obs.ObserveInt64(cm.cpuQuota, cpu.quota)
+		if cpu.period > 0 {
+			obs.ObserveFloat64(cm.cpuLimit, float64(cpu.quota)/float64(cpu.period))
+		}
+	}
+	observeIfSet(obs, cm.cpuPeriods, cpu.periods)
+	observeIfSet(obs, cm.cpuThrottled, cpu.throttledPeriods)
+	if cpu.throttledTime >= 0 {
+		obs.ObserveFloat64(cm.cpuThrottledTime, cpu.throttledTime.Seconds())
+	}
+
+	return nil
+}
+
+// observeIfSet observes v on inst, unless it is negative (unknown or unlimited).
+func observeIfSet(obs metric.Observer, inst metric.Int64Observable, v int64) {
+	if v >= 0 {
+		obs.ObserveInt64(inst, v)
+	}
+}
+
+// cgroupMemory holds the memory statistics of a cgroup. Unknown or unlimited
+// values are negative.
+type cgroupMemory struct {
+	limit, usage, inactiveFile int64
+}
+
+// cgroupCPU holds the CPU statistics of a cgroup. Unknown or unlimited values
+// are negative.
+type cgroupCPU struct {
+	quota, period             int64
+	periods, throttledPeriods int64
+	throttledTime             time.Duration
+}
+
+// cgroupReader reads the statistics of a cgroup.
+type cgroupReader interface {
+	memory() cgroupMemory
+	cpu() cgroupCPU
+}
+
+// detectCgroup returns a reader for the cgroup of the process below root, as
+// listed in cgroupFile, or nil if there is none.
+func detectCgroup(root, cgroupFile string) cgroupReader {
+	paths := readCgroupPaths(cgroupFile)
+
+	// The unified hierarchy (v2) has a cgroup.controllers file at its root.
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err == nil {
+		return cgroupV2{dir: cgroupDir(root, paths[""])}
+	}
+
+	// Otherwise, look for the v1 controllers, which may be mounted together.
+	v1 := cgroupV1{memoryDir: cgroupDir(filepath.Join(root, "memory"), paths["memory"])}
+	for _, name := range []string{"cpu,cpuacct", "cpu"} {
+		if dir := filepath.Join(root, name); isDir(dir) {
+			v1.cpuDir = cgroupDir(dir, paths["cpu"])
+			break
+		}
+	}
+	if !isDir(v1.memoryDir) && v1.cpuDir == "" {
+		return nil
+	}
+	return v1
+}
+
+// readCgroupPaths parses a /proc/<pid>/cgroup file into the cgroup path of each
+// controller; the path in the unified hierarchy has the empty controller name.
+func readCgroupPaths(path string) map[string]string {
+	paths := make(map[string]string)
+	f, err := os.Open(path)
+	if err != nil {
+		return paths
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// Lines are "hierarchy-ID:controller-list:cgroup-path".
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[1] == "" {
+			paths[""] = parts[2]
+			continue
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			paths[controller] = parts[2]
+		}
+	}
+	return paths
+}
+
+// cgroupDir returns the directory of the cgroup at path below mount, or mount
+// itself if that does not exist, as inside a container.
+func cgroupDir(mount, path string) string {
+	if path != "" {
+		if dir := filepath.Join(mount, path); isDir(dir) {
+			return dir
+		}
+	}
+	return mount
+}
+
+// isDir reports whether path is an existing directory.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// cgroupV2 reads a cgroup of the unified hierarchy.
+type cgroupV2 struct {
+	dir string
+}
+
+// memory implements cgroupReader.
+func (c cgroupV2) memory() cgroupMemory {
+	return cgroupMemory{
+		limit:        readCgroupInt(filepath.Join(c.dir, "memory.max")),
+		usage:        readCgroupInt(filepath.Join(c.dir, "memory.current")),
+		inactiveFile: readCgroupStat(filepath.Join(c.dir, "memory.stat")).get("inactive_file"),
+	}
+}
+
+// cpu implements cgroupReader.
+func (c cgroupV2) cpu() cgroupCPU {
+	cpu := cgroupCPU{quota: -1, period: -1, periods: -1, throttledPeriods: -1, throttledTime: -1}
+
+	// cpu.max holds "$MAX $PERIOD", where $MAX may be "max".
+	if data, err := os.ReadFile(filepath.Join(c.dir, "cpu.max")); err == nil {
+		if fields := strings.Fields(string(data)); len(fields) == 2 {
+			cpu.quota = parseCgroupInt(fields[0])
+			cpu.period = parseCgroupInt(fields[1])
+		}
+	}
+
+	stat := readCgroupStat(filepath.Join(c.dir, "cpu.stat"))
+	cpu.periods = stat.get("nr_periods")
+	cpu.throttledPeriods = stat.get("nr_throttled")
+	if usec := stat.get("throttled_usec"); usec >= 0 {
+		cpu.throttledTime = time.Duration(usec) * time.Microsecond
+	}
+	return cpu
+}
+
+// cgroupV1 reads the memory and cpu controllers of a v1 cgroup.
+type cgroupV1 struct {
+	memoryDir, cpuDir string
+}
+
+// memory implements cgroupReader.
+func (c cgroupV1) memory() cgroupMemory {
+	mem := cgroupMemory{
+		limit:        readCgroupInt(filepath.Join(c.memoryDir, "memory.limit_in_bytes")),
+		usage:        readCgroupInt(filepath.Join(c.memoryDir, "memory.usage_in_bytes")),
+		inactiveFile: readCgroupStat(filepath.Join(c.memoryDir, "memory.stat")).get("total_inactive_file"),
+	}
+	if mem.limit >= cgroupV1Unlimited {
+		mem.limit = -1
+	}
+	return mem
+}
+
+// cpu implements cgroupReader.
+func (c cgroupV1) cpu() cgroupCPU {
+	cpu := cgroupCPU{quota: -1, period: -1, periods: -1, throttledPeriods: -1, throttledTime: -1}
+	if c.cpuDir == "" {
+		return cpu
+	}
+
+	// A quota of -1 means unlimited.
+	cpu.quota = readCgroupInt(filepath.Join(c.cpuDir, "cpu.cfs_quota_us"))
+	cpu.period = readCgroupInt(filepath.Join(c.cpuDir, "cpu.cfs_period_us"))
+
+	stat := readCgroupStat(filepath.Join(c.cpuDir, "cpu.stat"))
+	cpu.periods = stat.get("nr_periods")
+	cpu.throttledPeriods = stat.get("nr_throttled")
+	if ns := stat.get("throttled_time"); ns >= 0 {
+		cpu.throttledTime = time.Duration(ns)
+	}
+	return cpu
+}
+
+// readCgroupInt reads a file holding a single integer, returning -1 if it
+// cannot be read or holds "max".
+func readCgroupInt(path string) int64 {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return -1
+	}
+	return parseCgroupInt(strings.TrimSpace(string(data)))
+}
+
+// parseCgroupInt parses a cgroup integer value, returning -1 for "max" or
+// malformed values.
+func parseCgroupInt(s string) int64 {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// cgroupStat holds the values of a flat keyed cgroup file such as memory.stat.
+// Missing keys are reported as -1.
+type cgroupStat map[string]int64
+
+// readCgroupStat reads a flat keyed file of "key value" lines.
+func readCgroupStat(path string) cgroupStat {
+	stat := make(cgroupStat)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return stat
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if key, value, ok := strings.Cut(line, " "); ok {
+			stat[key] = parseCgroupInt(strings.TrimSpace(value))
+		}
+	}
+	return stat
+}
+
+// get returns the value of key, or -1 if it is missing.
+func (s cgroupStat) get(key string) int64 {
+	if v, ok := s[key]; ok {
+		return v
+	}
+	return -1
+}
@@ -0,0 +1,120 @@
+package metrics_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	metricWrapper "github.com/janduursma/otel-metrics-wrapper-go"
+	"github.com/stretchr/testify/require"
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// collectContainerMetrics creates ContainerMetrics with the given options and
+// collects them once.
+func collectContainerMetrics(t *testing.T, opts ...metricWrapper.ContainerOption) metricdata.ResourceMetrics {
+	t.Helper()
+
+	reader := sdkMetric.NewManualReader()
+	_, err := metricWrapper.NewContainerMetrics(sdkMetric.NewMeterProvider(sdkMetric.WithReader(reader)).Meter("test-meter"), opts...)
+	require.NoError(t, err, "failed to create ContainerMetrics")
+	return collect(t, reader)
+}
+
+// writeFixture writes the given files, relative to dir, creating directories as needed.
+func writeFixture(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+}
+
+func TestContainerMetrics_CgroupV2(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		"cgroup.controllers": "cpu memory pids\n",
+		"memory.max":         "536870912\n",
+		"memory.current":     "104857600\n",
+		"memory.stat":        "anon 52428800\nfile 52428800\ninactive_file 20971520\nactive_file 31457280\n",
+		"cpu.max":            "200000 100000\n",
+		"cpu.stat":           "usage_usec 9000\nnr_periods 40\nnr_throttled 5\nthrottled_usec 1500000\n",
+	})
+
+	rm := collectContainerMetrics(t, metricWrapper.WithCgroupRoot(root))
+	require.EqualValues(t, 536870912, findGaugeValueByName(t, rm, "container.memory.limit"))
+	require.EqualValues(t, 104857600, findGaugeValueByName(t, rm, "container.memory.usage"))
+	require.EqualValues(t, 104857600-20971520, findGaugeValueByName(t, rm, "container.memory.working_set"))
+	require.EqualValues(t, 200000, findGaugeValueByName(t, rm, "container.cpu.quota"))
+	require.EqualValues(t, 100000, findGaugeValueByName(t, rm, "container.cpu.period"))
+	require.EqualValues(t, 2, findGaugeValueByName(t, rm, "container.cpu.limit"))
+	require.EqualValues(t, 40, findIntSumByName(t, rm, "container.cpu.periods"))
+	require.EqualValues(t, 5, findIntSumByName(t, rm, "container.cpu.throttled_periods"))
+	require.InDelta(t, 1.5, findFloatSumByName(t, rm, "container.cpu.throttled_time"), 1e-9)
+}
+
+func TestContainerMetrics_CgroupV2Unlimited(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		"cgroup.controllers": "cpu memory\n",
+		"memory.max":         "max\n",
+		"memory.current":     "4096\n",
+		"cpu.max":            "max 100000\n",
+	})
+
+	rm := collectContainerMetrics(t, metricWrapper.WithCgroupRoot(root))
+	require.EqualValues(t, 4096, findGaugeValueByName(t, rm, "container.memory.usage"))
+	require.EqualValues(t, 100000, findGaugeValueByName(t, rm, "container.cpu.period"))
+	for _, name := range []string{
+		"container.memory.limit", "container.memory.working_set",
+		"container.cpu.quota", "container.cpu.limit", "container.cpu.periods",
+	} {
+		require.False(t, hasDataPoints(rm, name), "expected no data points for %q", name)
+	}
+}
+
+func TestContainerMetrics_CgroupV1(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		"memory/memory.limit_in_bytes":  "268435456\n",
+		"memory/memory.usage_in_bytes":  "67108864\n",
+		"memory/memory.stat":            "cache 8388608\ninactive_file 1\ntotal_inactive_file 4194304\n",
+		"cpu,cpuacct/cpu.cfs_quota_us":  "50000\n",
+		"cpu,cpuacct/cpu.cfs_period_us": "100000\n",
+		"cpu,cpuacct/cpu.stat":          "nr_periods 30\nnr_throttled 12\nthrottled_time 250000000\n",
+	})
+
+	rm := collectContainerMetrics(t, metricWrapper.WithCgroupRoot(root))
+	require.EqualValues(t, 268435456, findGaugeValueByName(t, rm, "container.memory.limit"))
+	require.EqualValues(t, 67108864, findGaugeValueByName(t, rm, "container.memory.usage"))
+	require.EqualValues(t, 67108864-4194304, findGaugeValueByName(t, rm, "container.memory.working_set"))
+	require.EqualValues(t, 50000, findGaugeValueByName(t, rm, "container.cpu.quota"))
+	require.EqualValues(t, 100000, findGaugeValueByName(t, rm, "container.cpu.period"))
+	require.EqualValues(t, 30, findIntSumByName(t, rm, "container.cpu.periods"))
+	require.EqualValues(t, 12, findIntSumByName(t, rm, "container.cpu.throttled_periods"))
+	require.InDelta(t, 0.25, findFloatSumByName(t, rm, "container.cpu.throttled_time"), 1e-9)
+}
+
+func TestContainerMetrics_CgroupV1Unlimited(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, map[string]string{
+		"memory/memory.limit_in_bytes": "9223372036854771712\n",
+		"memory/memory.usage_in_bytes": "4096\n",
+		"cpu/cpu.cfs_quota_us":         "-1\n",
+		"cpu/cpu.cfs_period_us":        "100000\n",
+	})
+
+	rm := collectContainerMetrics(t, metricWrapper.WithCgroupRoot(root))
+	require.EqualValues(t, 4096, findGaugeValueByName(t, rm, "container.memory.usage"))
+	require.False(t, hasDataPoints(rm, "container.memory.limit"), "expected no memory limit")
+	require.False(t, hasDataPoints(rm, "container.cpu.quota"), "expected no CPU quota")
+}
+
+func TestContainerMetrics_Unavailable(t *testing.T) {
+	rm := collectContainerMetrics(t, metricWrapper.WithCgroupRoot(filepath.Join(t.TempDir(), "missing")))
+	require.False(t, hasDataPoints(rm, "container.memory.usage"), "expected no cgroup metrics without a cgroup")
+	require.False(t, hasDataPoints(rm, "container.go.gomaxprocs"), "GOMAXPROCS is reported by RuntimeMetrics")
+}
@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
@@ -13,13 +14,27 @@ type DBMetrics struct {
 	CallsTotal    metric.Int64Counter
 	CallsErrors   metric.Int64Counter
 	CallsDuration metric.Int64Histogram
+	RowsAffected  metric.Int64Histogram
+
+	// Asynchronous instruments for connection pools, observed by ObservePool.
+	PoolOpenConnections  metric.Int64ObservableGauge
+	PoolInUseConnections metric.Int64ObservableGauge
+	PoolIdleConnections  metric.Int64ObservableGauge
+	PoolMaxOpen          metric.Int64ObservableGauge
+	PoolWaitCount        metric.Int64ObservableCounter
+	PoolWaitDuration     metric.Int64ObservableCounter
+	PoolClosed           metric.Int64ObservableCounter
+
+	meter metric.Meter
 }
 
 // NewDBMetrics creates and registers a set of instruments for tracking database
-// interactions, including total and error counters, along with a histogram for query
-// duration. It returns a struct holding references to these instruments.
+// interactions, including total and error counters, along with histograms for query
+// duration and rows affected, and asynchronous instruments for connection pools
+// (see ObservePool).
+// It returns a struct holding references to these instruments.
 func NewDBMetrics(meter metric.Meter) (*DBMetrics, error) {
-	dbm := &DBMetrics{}
+	dbm := &DBMetrics{meter: meter}
 	var err error
 
 	if dbm.CallsTotal, err = meter.Int64Counter("db.calls.total"); err != nil {
@@ -28,15 +43,82 @@ func NewDBMetrics(meter metric.Meter) (*DBMetrics, error) {
 	if dbm.CallsErrors, err = meter.Int64Counter("db.calls.errors"); err != nil {
 		return nil, err
 	}
-	if dbm.CallsDuration, err = meter.Int64Histogram("db.calls.duration"); err != nil {
+	if dbm.CallsDuration, err = meter.Int64Histogram("db.calls.duration", metric.WithUnit("ms")); err != nil {
+		return nil, err
+	}
+	if dbm.RowsAffected, err = meter.Int64Histogram("db.calls.rows_affected", metric.WithUnit("{row}")); err != nil {
+		return nil, err
+	}
+	if err = dbm.createPoolInstruments(meter); err != nil {
 		return nil, err
 	}
 
 	return dbm, nil
 }
 
-// RecordDBCall increments the DB calls counter.
+// queryKey is the context key of the queryInfo set by ContextWithQuery.
+type queryKey struct{}
+
+// queryInfo is the SQL text set by ContextWithQuery, together with the
+// operation and table parsed from it, so that RecordDBCall and FinishDBCall
+// parse the statement once.
+type queryInfo struct {
+	query string
+
+	mu        sync.Mutex
+	parsed    bool
+	dbSystem  string
+	operation string
+	table     string
+}
+
+// parse returns the operation and table of the query, parsed with the rules of
+// dbSystem. The result is reused as long as dbSystem does not change.
+func (q *queryInfo) parse(dbSystem string) (operation, table string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.parsed || q.dbSystem != dbSystem {
+		q.operation, q.table = parserFor(dbSystem)(q.query)
+		q.dbSystem = dbSystem
+		q.parsed = true
+	}
+	return q.operation, q.table
+}
+
+// ContextWithQuery returns a copy of ctx carrying the SQL text of the statement
+// being executed. RecordDBCall and FinishDBCall parse it with ParseSQL (or
+// ParsePostgres and ParseMySQL for the Postgres, MySQL and MariaDB db systems)
+// to fill in the operation and table when they are passed as empty strings.
+// The statement is parsed once, on first use.
+func ContextWithQuery(ctx context.Context, query string) context.Context {
+	return context.WithValue(ctx, queryKey{}, &queryInfo{query: query})
+}
+
+// resolveQueryAttributes fills in an empty operation or table from the SQL text
+// carried by ctx, if any, parsed with the rules of dbSystem.
+func resolveQueryAttributes(ctx context.Context, dbSystem, operation, table string) (string, string) {
+	if operation != "" && table != "" {
+		return operation, table
+	}
+	q, ok := ctx.Value(queryKey{}).(*queryInfo)
+	if !ok {
+		return operation, table
+	}
+	parsedOperation, parsedTable := q.parse(dbSystem)
+	if operation == "" {
+		operation = parsedOperation
+	}
+	if table == "" {
+		table = parsedTable
+	}
+	return operation, table
+}
+
+// RecordDBCall increments the DB calls counter. An empty operation or table is
+// derived from the SQL text set with ContextWithQuery.
 func (dbm *DBMetrics) RecordDBCall(ctx context.Context, dbSystem, operation, table string) {
+	operation, table = resolveQueryAttributes(ctx, dbSystem, operation, table)
 	dbm.CallsTotal.Add(ctx, 1,
 		metric.WithAttributes(
 			attribute.String("db_system", dbSystem),
@@ -46,13 +128,15 @@ func (dbm *DBMetrics) RecordDBCall(ctx context.Context, dbSystem, operation, tab
 	)
 }
 
-// FinishDBCall records errors & latency.
+// FinishDBCall records errors & latency. An empty operation or table is derived
+// from the SQL text set with ContextWithQuery.
 func (dbm *DBMetrics) FinishDBCall(
 	ctx context.Context,
 	dbSystem, operation, table string,
 	err error,
 	start time.Time,
 ) {
+	operation, table = resolveQueryAttributes(ctx, dbSystem, operation, table)
 	if err != nil {
 		dbm.CallsErrors.Add(ctx, 1,
 			metric.WithAttributes(
@@ -73,3 +157,14 @@ func (dbm *DBMetrics) FinishDBCall(
 		),
 	)
 }
+
+// recordRowsAffected records the number of rows affected by a statement.
+func (dbm *DBMetrics) recordRowsAffected(ctx context.Context, dbSystem, operation, table string, rows int64) {
+	dbm.RowsAffected.Record(ctx, rows,
+		metric.WithAttributes(
+			attribute.String("db_system", dbSystem),
+			attribute.String("operation", operation),
+			attribute.String("table", table),
+		),
+	)
+}
@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveQueryAttributes_ParsesOnce(t *testing.T) {
+	ctx := ContextWithQuery(context.Background(), "DELETE FROM sessions WHERE id = $1")
+	q := ctx.Value(queryKey{}).(*queryInfo)
+
+	operation, table := resolveQueryAttributes(ctx, "postgres", "", "")
+	require.Equal(t, "DELETE", operation)
+	require.Equal(t, "sessions", table)
+
+	// The parsed attributes are reused for the same db system.
+	q.query = "SELECT * FROM users"
+	operation, table = resolveQueryAttributes(ctx, "postgres", "", "")
+	require.Equal(t, "DELETE", operation)
+	require.Equal(t, "sessions", table)
+
+	// Another db system parses the query with its own rules.
+	operation, table = resolveQueryAttributes(ctx, "mysql", "", "")
+	require.Equal(t, "SELECT", operation)
+	require.Equal(t, "users", table)
+}
@@ -8,13 +8,14 @@ import (
 
 	metricWrapper "github.com/janduursma/otel-metrics-wrapper-go"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
 
 	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 )
 
 func TestDBMetrics(t *testing.T) {
-	// Reset global state so that 'initialized' is false and 'meterProvider' is nil.
+	// Reset global state so that 'initialized' is false and 'defaultProvider' is nil.
 	metricWrapper.ResetState()
 
 	ctx := context.Background()
@@ -58,3 +59,26 @@ func TestDBMetrics(t *testing.T) {
 	errorsCount := findIntSumByName(t, rm, "db.calls.errors")
 	require.EqualValues(t, 1, errorsCount, "expected one error to be recorded.")
 }
+
+func TestDBMetrics_ContextWithQuery(t *testing.T) {
+	reader := sdkMetric.NewManualReader()
+	dbm, err := metricWrapper.NewDBMetrics(sdkMetric.NewMeterProvider(sdkMetric.WithReader(reader)).Meter("test-meter"))
+	require.NoError(t, err, "unexpected error creating DBMetrics.")
+
+	// Empty operation and table are parsed from the query in the context.
+	ctx := metricWrapper.ContextWithQuery(context.Background(), "DELETE FROM sessions WHERE id = $1")
+	start := time.Now()
+	dbm.RecordDBCall(ctx, "postgres", "", "")
+	dbm.FinishDBCall(ctx, "postgres", "", "", errors.New("simulated DB error"), start)
+
+	// Explicit values take precedence.
+	dbm.RecordDBCall(ctx, "postgres", "PURGE", "")
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm), "failed to collect metrics.")
+
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "db.calls.total", attribute.String("operation", "DELETE")))
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "db.calls.total", attribute.String("operation", "PURGE")))
+	require.EqualValues(t, 2, findIntSumByAttr(t, rm, "db.calls.total", attribute.String("table", "sessions")))
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "db.calls.errors", attribute.String("table", "sessions")))
+}
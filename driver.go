@@ -0,0 +1,499 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"time"
+)
+
+// DriverOption configures WrapDriver, WrapConnector and OpenDB.
+type DriverOption func(*driverConfig)
+
+// driverConfig holds the settings of an instrumented driver.
+type driverConfig struct {
+	parseQuery func(query string) (operation, table string)
+}
+
+// WithQueryParser sets the function used to derive the operation and table
+// attributes from the SQL text of a statement. By default, ParseMySQL is used for
+// the "mysql" and "mariadb" db systems, and ParseSQL for all others.
+func WithQueryParser(parser func(query string) (operation, table string)) DriverOption {
+	return func(cfg *driverConfig) {
+		cfg.parseQuery = parser
+	}
+}
+
+// Operations recorded for calls without SQL text.
+const (
+	opPrepare  = "PREPARE"
+	opBegin    = "BEGIN"
+	opCommit   = "COMMIT"
+	opRollback = "ROLLBACK"
+)
+
+// WrapDriver wraps d so that every Exec, Query, Prepare, Begin, Commit and
+// Rollback on its connections, statements and transactions is recorded through
+// RecordDBCall and FinishDBCall, with the given dbSystem and the operation
+// derived from the SQL text. Calls that the driver answers with driver.ErrSkip
+// are not recorded, as database/sql retries them in another way.
+//
+// The duration of a query covers the time until the driver returns its rows,
+// not the time spent iterating over them.
+func (dbm *DBMetrics) WrapDriver(d driver.Driver, dbSystem string, opts ...DriverOption) driver.Driver {
+	return &instrumentedDriver{driver: d, rec: newSQLRecorder(dbm, dbSystem, opts)}
+}
+
+// WrapConnector wraps c like WrapDriver, for use with sql.OpenDB.
+func (dbm *DBMetrics) WrapConnector(c driver.Connector, dbSystem string, opts ...DriverOption) driver.Connector {
+	rec := newSQLRecorder(dbm, dbSystem, opts)
+	return &instrumentedConnector{
+		connector: c,
+		driver:    &instrumentedDriver{driver: c.Driver(), rec: rec},
+		rec:       rec,
+	}
+}
+
+// OpenDB is like sql.Open, but returns a *sql.DB whose driver is instrumented
+// with WrapDriver. The driver must have been registered under driverName.
+func (dbm *DBMetrics) OpenDB(driverName, dataSourceName, dbSystem string, opts ...DriverOption) (*sql.DB, error) {
+	// sql.Open only looks up the driver; it does not connect.
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	d := db.Driver()
+	if err := db.Close(); err != nil {
+		return nil, err
+	}
+
+	wrapped := dbm.WrapDriver(d, dbSystem, opts...).(*instrumentedDriver)
+	c, err := wrapped.OpenConnector(dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(c), nil
+}
+
+// sqlRecorder records database calls through DBMetrics.
+type sqlRecorder struct {
+	dbm      *DBMetrics
+	dbSystem string
+	cfg      driverConfig
+}
+
+// newSQLRecorder applies opts and returns a recorder for dbSystem.
+func newSQLRecorder(dbm *DBMetrics, dbSystem string, opts []DriverOption) *sqlRecorder {
+	cfg := driverConfig{parseQuery: parserFor(dbSystem)}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &sqlRecorder{dbm: dbm, dbSystem: dbSystem, cfg: cfg}
+}
+
+// record runs call and records it under the given operation and table, unless
+// it returns driver.ErrSkip.
+func (r *sqlRecorder) record(ctx context.Context, operation, table string, call func() error) error {
+	start := time.Now()
+	err := call()
+	if errors.Is(err, driver.ErrSkip) {
+		return err
+	}
+	r.dbm.RecordDBCall(ctx, r.dbSystem, operation, table)
+	r.dbm.FinishDBCall(ctx, r.dbSystem, operation, table, err, start)
+	return err
+}
+
+// recordQuery runs call and records it under the operation and table of query.
+func (r *sqlRecorder) recordQuery(ctx context.Context, query string, call func() error) error {
+	operation, table := r.cfg.parseQuery(query)
+	return r.record(ctx, operation, table, call)
+}
+
+// instrumentedDriver is the driver.Driver returned by WrapDriver.
+type instrumentedDriver struct {
+	driver driver.Driver
+	rec    *sqlRecorder
+}
+
+// Open implements driver.Driver.
+func (d *instrumentedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{conn: conn, rec: d.rec}, nil
+}
+
+// OpenConnector implements driver.DriverContext.
+func (d *instrumentedDriver) OpenConnector(name string) (driver.Connector, error) {
+	if dc, ok := d.driver.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return &instrumentedConnector{connector: c, driver: d, rec: d.rec}, nil
+	}
+	return &dsnConnector{name: name, driver: d}, nil
+}
+
+// dsnConnector is a driver.Connector for drivers without driver.DriverContext.
+type dsnConnector struct {
+	name   string
+	driver *instrumentedDriver
+}
+
+// Connect implements driver.Connector.
+func (c *dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.name)
+}
+
+// Driver implements driver.Connector.
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// instrumentedConnector is the driver.Connector returned by WrapConnector.
+type instrumentedConnector struct {
+	connector driver.Connector
+	driver    *instrumentedDriver
+	rec       *sqlRecorder
+}
+
+// Connect implements driver.Connector.
+func (c *instrumentedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{conn: conn, rec: c.rec}, nil
+}
+
+// Driver implements driver.Connector.
+func (c *instrumentedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// Close closes the underlying connector if it implements io.Closer.
+func (c *instrumentedConnector) Close() error {
+	if closer, ok := c.connector.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// instrumentedConn is the driver.Conn of an instrumented driver. The optional
+// interfaces it implements fall back to the behavior of database/sql when the
+// underlying connection does not implement them.
+type instrumentedConn struct {
+	conn driver.Conn
+	rec  *sqlRecorder
+}
+
+// Prepare implements driver.Conn.
+func (c *instrumentedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext implements driver.ConnPrepareContext.
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	err := c.rec.record(ctx, opPrepare, "", func() error {
+		var err error
+		if pc, ok := c.conn.(driver.ConnPrepareContext); ok {
+			stmt, err = pc.PrepareContext(ctx, query)
+			return err
+		}
+		if stmt, err = c.conn.Prepare(query); err != nil {
+			return err
+		}
+		if err = ctx.Err(); err != nil {
+			_ = stmt.Close()
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.newStmt(stmt, query), nil
+}
+
+// newStmt wraps a statement prepared on the connection. The wrapper only
+// implements driver.ColumnConverter if stmt does, as database/sql prefers it
+// over its default conversions.
+func (c *instrumentedConn) newStmt(stmt driver.Stmt, query string) driver.Stmt {
+	s := &instrumentedStmt{stmt: stmt, query: query, conn: c.conn, rec: c.rec}
+	//nolint:staticcheck // ColumnConverter is still honored by database/sql.
+	if cc, ok := stmt.(driver.ColumnConverter); ok {
+		return &instrumentedConverterStmt{instrumentedStmt: s, cc: cc}
+	}
+	return s
+}
+
+// Close implements driver.Conn.
+func (c *instrumentedConn) Close() error {
+	return c.conn.Close()
+}
+
+// Begin implements driver.Conn.
+func (c *instrumentedConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx implements driver.ConnBeginTx.
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var tx driver.Tx
+	err := c.rec.record(ctx, opBegin, "", func() error {
+		var err error
+		if bt, ok := c.conn.(driver.ConnBeginTx); ok {
+			tx, err = bt.BeginTx(ctx, opts)
+			return err
+		}
+		// Mirror the checks database/sql makes for drivers without ConnBeginTx.
+		if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+			return errors.New("sql: driver does not support non-default isolation level")
+		}
+		if opts.ReadOnly {
+			return errors.New("sql: driver does not support read-only transactions")
+		}
+		//nolint:staticcheck // Begin is the only option for drivers without ConnBeginTx.
+		if tx, err = c.conn.Begin(); err != nil {
+			return err
+		}
+		if err = ctx.Err(); err != nil {
+			_ = tx.Rollback()
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedTx{tx: tx, ctx: ctx, rec: c.rec}, nil
+}
+
+// ExecContext implements driver.ExecerContext. It returns driver.ErrSkip if the
+// underlying connection cannot execute statements directly.
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	var res driver.Result
+	err := c.rec.recordQuery(ctx, query, func() error {
+		var err error
+		if ec, ok := c.conn.(driver.ExecerContext); ok {
+			res, err = ec.ExecContext(ctx, query, args)
+			return err
+		}
+		//nolint:staticcheck // Execer is still implemented by older drivers.
+		e, ok := c.conn.(driver.Execer)
+		if !ok {
+			return driver.ErrSkip
+		}
+		values, err := namedValuesToValues(ctx, args)
+		if err != nil {
+			return err
+		}
+		res, err = e.Exec(query, values)
+		return err
+	})
+	return res, err
+}
+
+// QueryContext implements driver.QueryerContext. It returns driver.ErrSkip if
+// the underlying connection cannot run queries directly.
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	var rows driver.Rows
+	err := c.rec.recordQuery(ctx, query, func() error {
+		var err error
+		if qc, ok := c.conn.(driver.QueryerContext); ok {
+			rows, err = qc.QueryContext(ctx, query, args)
+			return err
+		}
+		//nolint:staticcheck // Queryer is still implemented by older drivers.
+		q, ok := c.conn.(driver.Queryer)
+		if !ok {
+			return driver.ErrSkip
+		}
+		values, err := namedValuesToValues(ctx, args)
+		if err != nil {
+			return err
+		}
+		rows, err = q.Query(query, values)
+		return err
+	})
+	return rows, err
+}
+
+// Ping implements driver.Pinger.
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if p, ok := c.conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// ResetSession implements driver.SessionResetter.
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if sr, ok := c.conn.(driver.SessionResetter); ok {
+		return sr.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid implements driver.Validator.
+func (c *instrumentedConn) IsValid() bool {
+	if v, ok := c.conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue implements driver.NamedValueChecker.
+func (c *instrumentedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := c.conn.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// instrumentedStmt is the driver.Stmt of an instrumented driver. It keeps the
+// connection it was prepared on to consult its driver.NamedValueChecker.
+type instrumentedStmt struct {
+	stmt  driver.Stmt
+	query string
+	conn  driver.Conn
+	rec   *sqlRecorder
+}
+
+// Close implements driver.Stmt.
+func (s *instrumentedStmt) Close() error {
+	return s.stmt.Close()
+}
+
+// NumInput implements driver.Stmt.
+func (s *instrumentedStmt) NumInput() int {
+	return s.stmt.NumInput()
+}
+
+// Exec implements driver.Stmt.
+func (s *instrumentedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	var res driver.Result
+	err := s.rec.recordQuery(context.Background(), s.query, func() error {
+		var err error
+		//nolint:staticcheck // Exec is part of driver.Stmt.
+		res, err = s.stmt.Exec(args)
+		return err
+	})
+	return res, err
+}
+
+// Query implements driver.Stmt.
+func (s *instrumentedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	var rows driver.Rows
+	err := s.rec.recordQuery(context.Background(), s.query, func() error {
+		var err error
+		//nolint:staticcheck // Query is part of driver.Stmt.
+		rows, err = s.stmt.Query(args)
+		return err
+	})
+	return rows, err
+}
+
+// ExecContext implements driver.StmtExecContext.
+func (s *instrumentedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	var res driver.Result
+	err := s.rec.recordQuery(ctx, s.query, func() error {
+		var err error
+		if ec, ok := s.stmt.(driver.StmtExecContext); ok {
+			res, err = ec.ExecContext(ctx, args)
+			return err
+		}
+		values, err := namedValuesToValues(ctx, args)
+		if err != nil {
+			return err
+		}
+		//nolint:staticcheck // Exec is the fallback for statements without StmtExecContext.
+		res, err = s.stmt.Exec(values)
+		return err
+	})
+	return res, err
+}
+
+// QueryContext implements driver.StmtQueryContext.
+func (s *instrumentedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	var rows driver.Rows
+	err := s.rec.recordQuery(ctx, s.query, func() error {
+		var err error
+		if qc, ok := s.stmt.(driver.StmtQueryContext); ok {
+			rows, err = qc.QueryContext(ctx, args)
+			return err
+		}
+		values, err := namedValuesToValues(ctx, args)
+		if err != nil {
+			return err
+		}
+		//nolint:staticcheck // Query is the fallback for statements without StmtQueryContext.
+		rows, err = s.stmt.Query(values)
+		return err
+	})
+	return rows, err
+}
+
+// CheckNamedValue implements driver.NamedValueChecker. As database/sql only
+// consults the connection if the statement has no checker, this falls back to
+// the checker of the connection.
+func (s *instrumentedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := s.stmt.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	if nvc, ok := s.conn.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// instrumentedConverterStmt is an instrumentedStmt whose statement implements
+// driver.ColumnConverter.
+type instrumentedConverterStmt struct {
+	*instrumentedStmt
+	//nolint:staticcheck // ColumnConverter is still honored by database/sql.
+	cc driver.ColumnConverter
+}
+
+// ColumnConverter implements driver.ColumnConverter.
+func (s *instrumentedConverterStmt) ColumnConverter(idx int) driver.ValueConverter {
+	return s.cc.ColumnConverter(idx)
+}
+
+// instrumentedTx is the driver.Tx of an instrumented driver. Commit and
+// Rollback are recorded with the context the transaction was started with.
+type instrumentedTx struct {
+	tx  driver.Tx
+	ctx context.Context
+	rec *sqlRecorder
+}
+
+// Commit implements driver.Tx.
+func (t *instrumentedTx) Commit() error {
+	return t.rec.record(context.WithoutCancel(t.ctx), opCommit, "", t.tx.Commit)
+}
+
+// Rollback implements driver.Tx.
+func (t *instrumentedTx) Rollback() error {
+	return t.rec.record(context.WithoutCancel(t.ctx), opRollback, "", t.tx.Rollback)
+}
+
+// namedValuesToValues converts args for drivers without context support, which
+// cannot take named arguments, and checks that ctx is still alive.
+func namedValuesToValues(ctx context.Context, args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("sql: driver does not support the use of Named Parameters")
+		}
+		values[i] = arg.Value
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
@@ -0,0 +1,225 @@
+package metrics_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	metricWrapper "github.com/janduursma/otel-metrics-wrapper-go"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// fakeDriver is a minimal database/sql driver. Statements containing "fail"
+// return an error; all others succeed without returning rows.
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{query: query}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+func (fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if strings.Contains(query, "fail") {
+		return nil, errors.New("fake exec failure")
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type fakeStmt struct{ query string }
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return fakeConn{}.ExecContext(context.Background(), s.query, nil)
+}
+
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	if strings.Contains(s.query, "fail") {
+		return nil, errors.New("fake query failure")
+	}
+	return fakeRows{}, nil
+}
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string         { return []string{"id"} }
+func (fakeRows) Close() error              { return nil }
+func (fakeRows) Next([]driver.Value) error { return io.EOF }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+var registerFakeDriver sync.Once
+
+// newTestDB returns a *sql.DB backed by the instrumented fake driver, together
+// with the reader of its DBMetrics.
+func newTestDB(t *testing.T, opts ...metricWrapper.DriverOption) (*sql.DB, *sdkMetric.ManualReader) {
+	t.Helper()
+
+	registerFakeDriver.Do(func() { sql.Register("metrics-fake", fakeDriver{}) })
+
+	reader := sdkMetric.NewManualReader()
+	dbm, err := metricWrapper.NewDBMetrics(sdkMetric.NewMeterProvider(sdkMetric.WithReader(reader)).Meter("test-meter"))
+	require.NoError(t, err, "failed to create DBMetrics")
+
+	db, err := dbm.OpenDB("metrics-fake", "", "fake", opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db, reader
+}
+
+func TestOpenDB_ExecAndQuery(t *testing.T) {
+	db, reader := newTestDB(t)
+	ctx := context.Background()
+
+	_, err := db.ExecContext(ctx, "/* app */ insert into users (name) values (?)", "alice")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "UPDATE users SET fail = 1")
+	require.Error(t, err)
+
+	// The fake connection has no QueryerContext, so queries are prepared first.
+	rows, err := db.QueryContext(ctx, "SELECT id FROM users")
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+
+	rm := collect(t, reader)
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "db.calls.total", attribute.String("operation", "INSERT")))
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "db.calls.total", attribute.String("operation", "UPDATE")))
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "db.calls.total", attribute.String("operation", "PREPARE")))
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "db.calls.total", attribute.String("operation", "SELECT")))
+	require.EqualValues(t, 4, findIntSumByAttr(t, rm, "db.calls.total", attribute.String("db_system", "fake")))
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "db.calls.errors", attribute.String("operation", "UPDATE")))
+	require.EqualValues(t, 4, findHistogramCountByName(t, rm, "db.calls.duration"))
+}
+
+func TestOpenDB_Transaction(t *testing.T) {
+	db, reader := newTestDB(t)
+	ctx := context.Background()
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	stmt, err := tx.PrepareContext(ctx, "DELETE FROM sessions")
+	require.NoError(t, err)
+	_, err = stmt.ExecContext(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stmt.Close())
+	require.NoError(t, tx.Commit())
+
+	tx, err = db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+
+	// Drivers without ConnBeginTx cannot start read-only transactions.
+	_, err = db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	require.Error(t, err)
+
+	rm := collect(t, reader)
+	require.EqualValues(t, 3, findIntSumByAttr(t, rm, "db.calls.total", attribute.String("operation", "BEGIN")))
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "db.calls.errors", attribute.String("operation", "BEGIN")))
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "db.calls.total", attribute.String("operation", "DELETE")))
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "db.calls.total", attribute.String("operation", "COMMIT")))
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "db.calls.total", attribute.String("operation", "ROLLBACK")))
+}
+
+func TestOpenDB_QueryParser(t *testing.T) {
+	db, reader := newTestDB(t, metricWrapper.WithQueryParser(func(string) (string, string) {
+		return "SELECT", "accounts"
+	}))
+
+	_, err := db.ExecContext(context.Background(), "CALL refresh_accounts()")
+	require.NoError(t, err)
+
+	rm := collect(t, reader)
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "db.calls.total", attribute.String("table", "accounts")))
+}
+
+func TestOpenDB_UnknownDriver(t *testing.T) {
+	dbm, err := metricWrapper.NewDBMetrics(sdkMetric.NewMeterProvider().Meter("test-meter"))
+	require.NoError(t, err)
+
+	_, err = dbm.OpenDB("no-such-driver", "", "fake")
+	require.Error(t, err)
+}
+
+// point is a driver-specific argument type, accepted by checkerConn and
+// converted by converterStmt.
+type point struct{ x, y int }
+
+// checkerDriver is a fake driver whose connection, but not its statements,
+// accepts point arguments, as pgx and go-sql-driver/mysql do.
+type checkerDriver struct{}
+
+func (checkerDriver) Open(string) (driver.Conn, error) { return checkerConn{}, nil }
+
+type checkerConn struct{ fakeConn }
+
+func (checkerConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if _, ok := nv.Value.(point); ok {
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+// converterDriver is a fake driver whose statements convert point arguments
+// with a driver.ColumnConverter.
+type converterDriver struct{}
+
+func (converterDriver) Open(string) (driver.Conn, error) { return converterConn{}, nil }
+
+type converterConn struct{ fakeConn }
+
+func (converterConn) Prepare(query string) (driver.Stmt, error) {
+	return converterStmt{fakeStmt{query: query}}, nil
+}
+
+type converterStmt struct{ fakeStmt }
+
+func (converterStmt) ColumnConverter(int) driver.ValueConverter { return pointConverter{} }
+
+type pointConverter struct{}
+
+func (pointConverter) ConvertValue(v any) (driver.Value, error) {
+	if p, ok := v.(point); ok {
+		return fmt.Sprintf("(%d,%d)", p.x, p.y), nil
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
+var registerArgDrivers sync.Once
+
+func TestOpenDB_DriverArguments(t *testing.T) {
+	registerArgDrivers.Do(func() {
+		sql.Register("metrics-fake-checker", checkerDriver{})
+		sql.Register("metrics-fake-converter", converterDriver{})
+	})
+
+	for _, driverName := range []string{"metrics-fake-checker", "metrics-fake-converter"} {
+		t.Run(driverName, func(t *testing.T) {
+			dbm, err := metricWrapper.NewDBMetrics(sdkMetric.NewMeterProvider().Meter("test-meter"))
+			require.NoError(t, err)
+			db, err := dbm.OpenDB(driverName, "", "fake")
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = db.Close() })
+
+			stmt, err := db.Prepare("UPDATE shapes SET origin = ?")
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = stmt.Close() })
+			_, err = stmt.Exec(point{1, 2})
+			require.NoError(t, err, "driver-specific argument rejected")
+		})
+	}
+}
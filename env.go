@@ -0,0 +1,291 @@
+package metrics
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// Standard OpenTelemetry environment variables understood by NewConfigFromEnv.
+// Signal-specific OTEL_EXPORTER_OTLP_METRICS_* variables take precedence over
+// their generic OTEL_EXPORTER_OTLP_* counterparts.
+const (
+	envMetricsExporter        = "OTEL_METRICS_EXPORTER"
+	envOTLPEndpoint           = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	envOTLPMetricsEndpoint    = "OTEL_EXPORTER_OTLP_METRICS_ENDPOINT"
+	envOTLPProtocol           = "OTEL_EXPORTER_OTLP_PROTOCOL"
+	envOTLPMetricsProtocol    = "OTEL_EXPORTER_OTLP_METRICS_PROTOCOL"
+	envOTLPInsecure           = "OTEL_EXPORTER_OTLP_INSECURE"
+	envOTLPMetricsInsecure    = "OTEL_EXPORTER_OTLP_METRICS_INSECURE"
+	envOTLPCertificate        = "OTEL_EXPORTER_OTLP_CERTIFICATE"
+	envOTLPMetricsCertificate = "OTEL_EXPORTER_OTLP_METRICS_CERTIFICATE"
+	envOTLPClientCert         = "OTEL_EXPORTER_OTLP_CLIENT_CERTIFICATE"
+	envOTLPMetricsClientCert  = "OTEL_EXPORTER_OTLP_METRICS_CLIENT_CERTIFICATE"
+	envOTLPClientKey          = "OTEL_EXPORTER_OTLP_CLIENT_KEY"
+	envOTLPMetricsClientKey   = "OTEL_EXPORTER_OTLP_METRICS_CLIENT_KEY"
+	envOTLPHeaders            = "OTEL_EXPORTER_OTLP_HEADERS"
+	envOTLPMetricsHeaders     = "OTEL_EXPORTER_OTLP_METRICS_HEADERS"
+	envOTLPCompression        = "OTEL_EXPORTER_OTLP_COMPRESSION"
+	envOTLPMetricsCompression = "OTEL_EXPORTER_OTLP_METRICS_COMPRESSION"
+	envOTLPTimeout            = "OTEL_EXPORTER_OTLP_TIMEOUT"
+	envOTLPMetricsTimeout     = "OTEL_EXPORTER_OTLP_METRICS_TIMEOUT"
+	envServiceName            = "OTEL_SERVICE_NAME"
+	envResourceAttributes     = "OTEL_RESOURCE_ATTRIBUTES"
+	envMetricExportInterval   = "OTEL_METRIC_EXPORT_INTERVAL"
+	envMetricExportTimeout    = "OTEL_METRIC_EXPORT_TIMEOUT"
+)
+
+// defaultHTTPMetricsPath is the path appended to a generic OTLP/HTTP endpoint.
+const defaultHTTPMetricsPath = "/v1/metrics"
+
+// NewConfigFromEnv creates a new Config from the standard OTEL_* environment variables.
+//
+// Precedence, from lowest to highest, is: the NewConfig defaults, the environment,
+// and finally the supplied options. OTEL_SERVICE_NAME wins over a service.name
+// entry in OTEL_RESOURCE_ATTRIBUTES, and the Environment is taken from the
+// deployment.environment resource attribute. The resulting Config is checked
+// with the same validation as InitMetrics.
+func NewConfigFromEnv(opts ...Option) (Config, error) {
+	c := NewConfig("", "", "")
+	endpoint, err := applyEnv(&c, os.Getenv)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid OTEL environment: %w", err)
+	}
+
+	// Explicit options override anything read from the environment.
+	envEndpoint := c.OTLPEndpoint
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	// The path of an endpoint URL depends on the final protocol, unless an
+	// option replaced the endpoint.
+	if endpoint != nil && c.OTLPEndpoint == envEndpoint {
+		endpoint.resolve(&c)
+	}
+
+	if err := validateConfig(c); err != nil {
+		return Config{}, fmt.Errorf("invalid OTLP metrics config: %w", err)
+	}
+	return c, nil
+}
+
+// applyEnv fills cfg from the environment variables returned by getenv. An
+// endpoint URL is returned to be resolved once the protocol is final.
+func applyEnv(cfg *Config, getenv func(string) string) (*envEndpoint, error) {
+	if v := getenv(envMetricsExporter); v != "" {
+		if err := applyEnvExporters(cfg, v); err != nil {
+			return nil, fmt.Errorf("%s: %w", envMetricsExporter, err)
+		}
+	}
+
+	if _, v := firstEnv(getenv, envOTLPMetricsProtocol, envOTLPProtocol); v != "" {
+		cfg.Protocol = v
+	}
+
+	if name, v := firstEnv(getenv, envOTLPMetricsInsecure, envOTLPInsecure); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		cfg.OTLPInsecure = b
+	}
+
+	// The endpoint scheme, if any, determines whether TLS is used.
+	var endpoint *envEndpoint
+	if v := getenv(envOTLPMetricsEndpoint); v != "" {
+		var err error
+		if endpoint, err = applyEnvEndpoint(cfg, v, false); err != nil {
+			return nil, fmt.Errorf("%s: %w", envOTLPMetricsEndpoint, err)
+		}
+	} else if v := getenv(envOTLPEndpoint); v != "" {
+		var err error
+		if endpoint, err = applyEnvEndpoint(cfg, v, true); err != nil {
+			return nil, fmt.Errorf("%s: %w", envOTLPEndpoint, err)
+		}
+	}
+
+	if _, v := firstEnv(getenv, envOTLPMetricsCertificate, envOTLPCertificate); v != "" {
+		cfg.OTLPCAFile = v
+	}
+	if _, v := firstEnv(getenv, envOTLPMetricsClientCert, envOTLPClientCert); v != "" {
+		cfg.OTLPClientCertFile = v
+	}
+	if _, v := firstEnv(getenv, envOTLPMetricsClientKey, envOTLPClientKey); v != "" {
+		cfg.OTLPClientKeyFile = v
+	}
+
+	if name, v := firstEnv(getenv, envOTLPMetricsHeaders, envOTLPHeaders); v != "" {
+		headers, err := parseKeyValueList(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		cfg.OTLPHeaders = headers
+	}
+
+	if _, v := firstEnv(getenv, envOTLPMetricsCompression, envOTLPCompression); v != "" {
+		cfg.Compression = v
+	}
+
+	if name, v := firstEnv(getenv, envMetricExportTimeout, envOTLPMetricsTimeout, envOTLPTimeout); v != "" {
+		d, err := parseMillis(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		cfg.ExportTimeout = d
+	}
+
+	if v := getenv(envResourceAttributes); v != "" {
+		attrs, err := parseKeyValueList(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", envResourceAttributes, err)
+		}
+		if name, ok := attrs[string(semconv.ServiceNameKey)]; ok {
+			cfg.ServiceName = name
+			delete(attrs, string(semconv.ServiceNameKey))
+		}
+		if env, ok := attrs[string(semconv.DeploymentEnvironmentKey)]; ok {
+			cfg.Environment = env
+			delete(attrs, string(semconv.DeploymentEnvironmentKey))
+		}
+		if len(attrs) > 0 {
+			cfg.ResourceAttributes = attrs
+		}
+	}
+	if v := getenv(envServiceName); v != "" {
+		cfg.ServiceName = v
+	}
+
+	if v := getenv(envMetricExportInterval); v != "" {
+		d, err := parseMillis(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", envMetricExportInterval, err)
+		}
+		cfg.PushInterval = d
+	}
+
+	return endpoint, nil
+}
+
+// applyEnvExporters applies a comma-separated OTEL_METRICS_EXPORTER list,
+// e.g. "otlp,prometheus". The spec's "console" selects the stdout exporter.
+// Leaving out a push exporter relies on the Prometheus reader alone.
+func applyEnvExporters(cfg *Config, list string) error {
+	cfg.Exporter = ExporterNone
+	cfg.Prometheus = false
+	for _, name := range strings.Split(list, ",") {
+		switch strings.TrimSpace(name) {
+		case ExporterOTLP:
+			cfg.Exporter = ExporterOTLP
+		case "console", ExporterStdout:
+			cfg.Exporter = ExporterStdout
+		case "prometheus":
+			cfg.Prometheus = true
+		case ExporterNone, "":
+		default:
+			return fmt.Errorf("unsupported exporter %q", name)
+		}
+	}
+	return nil
+}
+
+// envEndpoint is an endpoint URL read from the environment. Whether its path is
+// kept depends on the protocol, which options may still change.
+type envEndpoint struct {
+	host    string
+	path    string
+	generic bool
+}
+
+// resolve sets the OTLPEndpoint of cfg in the format expected by its protocol.
+// For OTLP/HTTP, a generic endpoint is treated as a base URL to which
+// "/v1/metrics" is appended, while a signal-specific endpoint is used as-is.
+func (e *envEndpoint) resolve(cfg *Config) {
+	cfg.OTLPEndpoint = e.host
+	if otlpProtocol(*cfg) == ProtocolHTTPProtobuf {
+		p := e.path
+		if e.generic {
+			p = path.Join("/", p, defaultHTTPMetricsPath)
+		}
+		cfg.OTLPEndpoint += p
+	}
+}
+
+// applyEnvEndpoint applies an OTEL endpoint URL to cfg. Its scheme selects
+// whether TLS is used, and the URL is returned to be resolved with resolve. A
+// value without a scheme, such as "127.0.0.1:4317", is used verbatim, and nil
+// is returned.
+func applyEnvEndpoint(cfg *Config, endpoint string, generic bool) (*envEndpoint, error) {
+	if !strings.Contains(endpoint, "://") {
+		cfg.OTLPEndpoint = endpoint
+		return nil, nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("missing host in %q", endpoint)
+	}
+
+	switch u.Scheme {
+	case "http":
+		cfg.OTLPInsecure = true
+	case "https":
+		cfg.OTLPInsecure = false
+	default:
+		return nil, fmt.Errorf("unsupported endpoint scheme %q", u.Scheme)
+	}
+
+	cfg.OTLPEndpoint = u.Host
+	return &envEndpoint{host: u.Host, path: u.Path, generic: generic}, nil
+}
+
+// parseMillis parses a duration given as an integer number of milliseconds.
+func parseMillis(v string) (time.Duration, error) {
+	ms, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// firstEnv returns the name and value of the first variable among keys with a
+// non-empty value.
+func firstEnv(getenv func(string) string, keys ...string) (name, value string) {
+	for _, k := range keys {
+		if v := strings.TrimSpace(getenv(k)); v != "" {
+			return k, v
+		}
+	}
+	return "", ""
+}
+
+// parseKeyValueList parses the "key1=value1,key2=value2" format used by
+// OTEL_RESOURCE_ATTRIBUTES and OTEL_EXPORTER_OTLP_HEADERS. Values may be
+// percent-encoded.
+func parseKeyValueList(s string) (map[string]string, error) {
+	out := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid key-value pair %q", pair)
+		}
+		decoded, err := url.PathUnescape(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %q: %w", k, err)
+		}
+		out[k] = decoded
+	}
+	return out, nil
+}
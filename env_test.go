@@ -0,0 +1,191 @@
+package metrics_test
+
+import (
+	"testing"
+	"time"
+
+	metricWrapper "github.com/janduursma/otel-metrics-wrapper-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConfigFromEnv_GRPC(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4317")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "api-key=secret,x-tenant=acme%20corp")
+	t.Setenv("OTEL_SERVICE_NAME", "env-service")
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "service.name=ignored,deployment.environment=staging,team=core")
+	t.Setenv("OTEL_METRIC_EXPORT_INTERVAL", "2500")
+	t.Setenv("OTEL_EXPORTER_OTLP_COMPRESSION", "gzip")
+	t.Setenv("OTEL_EXPORTER_OTLP_TIMEOUT", "7000")
+
+	cfg, err := metricWrapper.NewConfigFromEnv()
+	require.NoError(t, err)
+
+	require.Equal(t, "collector:4317", cfg.OTLPEndpoint)
+	require.Equal(t, metricWrapper.ProtocolGRPC, cfg.Protocol)
+	require.True(t, cfg.OTLPInsecure, "http scheme should select an insecure connection")
+	require.Equal(t, map[string]string{"api-key": "secret", "x-tenant": "acme corp"}, cfg.OTLPHeaders)
+	require.Equal(t, "env-service", cfg.ServiceName, "OTEL_SERVICE_NAME should win over service.name")
+	require.Equal(t, "staging", cfg.Environment)
+	require.Equal(t, map[string]string{"team": "core"}, cfg.ResourceAttributes)
+	require.Equal(t, 2500*time.Millisecond, cfg.PushInterval)
+	require.Equal(t, metricWrapper.CompressionGzip, cfg.Compression)
+	require.Equal(t, 7*time.Second, cfg.ExportTimeout)
+
+	// OTEL_METRIC_EXPORT_TIMEOUT takes precedence over the exporter timeout.
+	t.Setenv("OTEL_METRIC_EXPORT_TIMEOUT", "3000")
+	cfg, err = metricWrapper.NewConfigFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, 3*time.Second, cfg.ExportTimeout)
+}
+
+func TestNewConfigFromEnv_HTTPEndpoints(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/protobuf")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318/otlp")
+	t.Setenv("OTEL_SERVICE_NAME", "env-service")
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "deployment.environment=prod")
+
+	// A generic endpoint is used as a base URL.
+	cfg, err := metricWrapper.NewConfigFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, metricWrapper.ProtocolHTTPProtobuf, cfg.Protocol)
+	require.Equal(t, "collector:4318/otlp/v1/metrics", cfg.OTLPEndpoint)
+
+	// A signal-specific endpoint takes precedence and is used as-is.
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "http://metrics:4318/custom")
+	cfg, err = metricWrapper.NewConfigFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, "metrics:4318/custom", cfg.OTLPEndpoint)
+}
+
+func TestNewConfigFromEnv_OptionsOverrideEnv(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4317")
+	t.Setenv("OTEL_SERVICE_NAME", "env-service")
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "deployment.environment=staging")
+	t.Setenv("OTEL_METRIC_EXPORT_INTERVAL", "2500")
+
+	cfg, err := metricWrapper.NewConfigFromEnv(
+		metricWrapper.WithPushInterval(30 * time.Second),
+	)
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Second, cfg.PushInterval, "explicit options should override the environment")
+
+	// The endpoint path is resolved against the protocol set by an option.
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318/custom")
+	cfg, err = metricWrapper.NewConfigFromEnv(
+		metricWrapper.WithOTLPProtocol(metricWrapper.ProtocolHTTPProtobuf),
+	)
+	require.NoError(t, err)
+	require.Equal(t, "collector:4318/custom/v1/metrics", cfg.OTLPEndpoint)
+
+	// Options for the required fields win over the environment.
+	cfg, err = metricWrapper.NewConfigFromEnv(
+		metricWrapper.WithOTLPEndpoint("other:4317"),
+		metricWrapper.WithServiceName("option-service"),
+		metricWrapper.WithEnvironment("prod"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, "other:4317", cfg.OTLPEndpoint)
+	require.Equal(t, "option-service", cfg.ServiceName)
+	require.Equal(t, "prod", cfg.Environment)
+
+	// An endpoint set by an option is used as-is, whatever the protocol.
+	cfg, err = metricWrapper.NewConfigFromEnv(
+		metricWrapper.WithOTLPProtocol(metricWrapper.ProtocolHTTPProtobuf),
+		metricWrapper.WithOTLPEndpoint("other:4318/v1/metrics"),
+	)
+	require.NoError(t, err)
+	require.Equal(t, "other:4318/v1/metrics", cfg.OTLPEndpoint)
+}
+
+func TestNewConfigFromEnv_BareEndpoint(t *testing.T) {
+	t.Setenv("OTEL_SERVICE_NAME", "env-service")
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "deployment.environment=staging")
+
+	// Endpoints without a scheme are used verbatim.
+	for _, endpoint := range []string{"127.0.0.1:4317", "localhost:4317", "[::1]:4317"} {
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", endpoint)
+		cfg, err := metricWrapper.NewConfigFromEnv()
+		require.NoError(t, err, endpoint)
+		require.Equal(t, endpoint, cfg.OTLPEndpoint)
+	}
+}
+
+func TestNewConfigFromEnv_Invalid(t *testing.T) {
+	// Missing endpoint, service name and environment fail validation.
+	_, err := metricWrapper.NewConfigFromEnv()
+	require.Error(t, err)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4317")
+	t.Setenv("OTEL_SERVICE_NAME", "env-service")
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "deployment.environment=staging")
+
+	// A malformed interval is reported.
+	t.Setenv("OTEL_METRIC_EXPORT_INTERVAL", "ten seconds")
+	_, err = metricWrapper.NewConfigFromEnv()
+	require.ErrorContains(t, err, "OTEL_METRIC_EXPORT_INTERVAL")
+	t.Setenv("OTEL_METRIC_EXPORT_INTERVAL", "")
+
+	// Errors name the variable that was read.
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_INSECURE", "maybe")
+	_, err = metricWrapper.NewConfigFromEnv()
+	require.ErrorContains(t, err, "OTEL_EXPORTER_OTLP_METRICS_INSECURE")
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_INSECURE", "")
+
+	t.Setenv("OTEL_EXPORTER_OTLP_TIMEOUT", "soon")
+	_, err = metricWrapper.NewConfigFromEnv()
+	require.ErrorContains(t, err, "OTEL_EXPORTER_OTLP_TIMEOUT")
+	t.Setenv("OTEL_EXPORTER_OTLP_TIMEOUT", "")
+
+	// An unsupported protocol is rejected by validation.
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/json")
+	_, err = metricWrapper.NewConfigFromEnv()
+	require.ErrorContains(t, err, "unsupported Protocol")
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "")
+
+	// An https endpoint requires a CA certificate.
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "https://collector:4317")
+	_, err = metricWrapper.NewConfigFromEnv()
+	require.ErrorContains(t, err, "CA file required")
+
+	t.Setenv("OTEL_EXPORTER_OTLP_CERTIFICATE", "/etc/ssl/collector-ca.pem")
+	cfg, err := metricWrapper.NewConfigFromEnv()
+	require.NoError(t, err)
+	require.False(t, cfg.OTLPInsecure)
+	require.Equal(t, "/etc/ssl/collector-ca.pem", cfg.OTLPCAFile)
+
+	// Malformed resource attributes are reported.
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "novalue")
+	_, err = metricWrapper.NewConfigFromEnv()
+	require.ErrorContains(t, err, "OTEL_RESOURCE_ATTRIBUTES")
+}
+
+func TestNewConfigFromEnv_Exporters(t *testing.T) {
+	t.Setenv("OTEL_SERVICE_NAME", "env-service")
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "deployment.environment=prod")
+
+	// Prometheus only; no OTLP endpoint is required.
+	t.Setenv("OTEL_METRICS_EXPORTER", "prometheus")
+	cfg, err := metricWrapper.NewConfigFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, metricWrapper.ExporterNone, cfg.Exporter)
+	require.True(t, cfg.Prometheus)
+
+	// Both readers.
+	t.Setenv("OTEL_METRICS_EXPORTER", "otlp, prometheus")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4317")
+	cfg, err = metricWrapper.NewConfigFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, metricWrapper.ExporterOTLP, cfg.Exporter)
+	require.True(t, cfg.Prometheus)
+
+	// The spec's console exporter maps onto the stdout exporter.
+	t.Setenv("OTEL_METRICS_EXPORTER", "console")
+	cfg, err = metricWrapper.NewConfigFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, metricWrapper.ExporterStdout, cfg.Exporter)
+	require.False(t, cfg.Prometheus)
+
+	t.Setenv("OTEL_METRICS_EXPORTER", "zipkin")
+	_, err = metricWrapper.NewConfigFromEnv()
+	require.ErrorContains(t, err, "OTEL_METRICS_EXPORTER")
+}
@@ -1,17 +1,159 @@
 package metrics
 
 import (
+	"cmp"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"net"
+	"net/url"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 
-	"github.com/jackc/pgconn"
 	"github.com/jackc/pgerrcode"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+// Names of the built-in error classifiers. Registering a classifier under one of
+// these names replaces the built-in rule; UnregisterErrorClassifier removes it.
+const (
+	ClassifierSelfReported = "self_reported"
+	ClassifierContext      = "context"
+	ClassifierNetwork      = "network"
+	ClassifierInvalidInput = "invalid_input"
+	ClassifierSQL          = "sql"
+	ClassifierSQLState     = "sqlstate"
+	ClassifierSQLite       = "sqlite"
+	ClassifierGRPC         = "grpc"
+
+	// ClassifierMySQL is registered by importing the mysqlerr package, as the
+	// error type of go-sql-driver/mysql can only be matched by importing the
+	// driver; see MySQLErrorType.
+	ClassifierMySQL = "mysql"
+)
+
+// ErrorClassifier maps an error to the category recorded in the error_type
+// attribute of the error counters. ClassifyError returns "" for errors it does
+// not recognize, so that the next classifier is consulted.
+type ErrorClassifier interface {
+	ClassifyError(err error) string
+}
+
+// ErrorClassifierFunc is an adapter to allow the use of ordinary functions as
+// error classifiers.
+type ErrorClassifierFunc func(err error) string
+
+// ClassifyError calls f(err).
+func (f ErrorClassifierFunc) ClassifyError(err error) string {
+	return f(err)
+}
+
+// registeredClassifier is an ErrorClassifier in the registry.
+type registeredClassifier struct {
+	name       string
+	classifier ErrorClassifier
+	priority   int
+}
+
+var (
+	// classifiersMu serializes changes to the registry.
+	classifiersMu sync.Mutex
+
+	// classifiers holds the registered classifiers in the order they are
+	// consulted. It is replaced, never modified, so that classifyError can read
+	// it without locking.
+	classifiers atomic.Pointer[[]registeredClassifier]
+)
+
+func init() {
+	RegisterErrorClassifier(ClassifierSelfReported, ErrorClassifierFunc(classifySelfReportedError), 1000)
+	RegisterErrorClassifier(ClassifierContext, ErrorClassifierFunc(classifyContextError), 500)
+	RegisterErrorClassifier(ClassifierNetwork, ErrorClassifierFunc(classifyNetworkError), 400)
+	RegisterErrorClassifier(ClassifierInvalidInput, ErrorClassifierFunc(classifyInvalidInputError), 300)
+	RegisterErrorClassifier(ClassifierSQL, ErrorClassifierFunc(classifySQLError), 200)
+	RegisterErrorClassifier(ClassifierSQLState, ErrorClassifierFunc(classifySQLStateError), 200)
+	RegisterErrorClassifier(ClassifierSQLite, ErrorClassifierFunc(classifySQLiteError), 200)
+	RegisterErrorClassifier(ClassifierGRPC, ErrorClassifierFunc(classifyGRPCError), 100)
+}
+
+// RegisterErrorClassifier adds a classifier that is consulted when recording
+// errors, by FinishDBCall, FinishExternalCall and the integrations built on
+// them. Classifiers are consulted in order of descending priority (ties in order
+// of name) until one returns a non-empty category; if none does, the error is
+// recorded as "unknown".
+//
+// Registering a classifier under an existing name replaces it, which allows
+// overriding the built-in classifiers (see ClassifierContext and friends). The
+// classifier for errors implementing MetricErrorTyper has priority 1000 and the
+// heuristic rules have priorities 500 down to 100 in steps of 100, so a
+// classifier with a priority between 500 and 1000 runs before all heuristics
+// but still respects self-reported categories. It is safe to call
+// RegisterErrorClassifier concurrently with recording metrics.
+func RegisterErrorClassifier(name string, classifier ErrorClassifier, priority int) {
+	updateClassifiers(func(list []registeredClassifier) []registeredClassifier {
+		list = slices.DeleteFunc(list, func(rc registeredClassifier) bool { return rc.name == name })
+		list = append(list, registeredClassifier{name: name, classifier: classifier, priority: priority})
+		slices.SortFunc(list, func(a, b registeredClassifier) int {
+			if c := cmp.Compare(b.priority, a.priority); c != 0 {
+				return c
+			}
+			return strings.Compare(a.name, b.name)
+		})
+		return list
+	})
+}
+
+// UnregisterErrorClassifier removes the classifier registered under name, if
+// any, including the built-in ones.
+func UnregisterErrorClassifier(name string) {
+	updateClassifiers(func(list []registeredClassifier) []registeredClassifier {
+		return slices.DeleteFunc(list, func(rc registeredClassifier) bool { return rc.name == name })
+	})
+}
+
+// updateClassifiers replaces the registry with the result of update, which is
+// passed a copy of the current registry.
+func updateClassifiers(update func([]registeredClassifier) []registeredClassifier) {
+	classifiersMu.Lock()
+	defer classifiersMu.Unlock()
+
+	var list []registeredClassifier
+	if current := classifiers.Load(); current != nil {
+		list = slices.Clone(*current)
+	}
+	list = update(list)
+	classifiers.Store(&list)
+}
+
+// MetricErrorTyper is implemented by errors that know their own category. The
+// first error in the chain of a recorded error, as unwrapped by errors.Unwrap
+// and errors.Join, that implements MetricErrorTyper and returns a non-empty
+// category determines the error_type attribute, taking precedence over the
+// heuristic rules.
+//
+// The category is sanitized to at most 64 characters from [A-Za-z0-9_.-];
+// other characters are replaced with underscores.
+type MetricErrorTyper interface {
+	MetricErrorType() string
+}
+
+// maxErrorTypeLen is the maximum length of a self-reported error category.
+const maxErrorTypeLen = 64
+
+// sqlStateError is implemented by database errors that carry a SQLSTATE code,
+// such as *pgconn.PgError.
+type sqlStateError interface {
+	error
+	SQLState() string
+}
+
 // classifyError inspects the given error and returns a
 // string-based category ("timeout", "network", "invalid_input" etc.)
 // This allows tracking the number of errors that fall into the different categories.
@@ -20,44 +162,337 @@ func classifyError(err error) string {
 		return "" // no error
 	}
 
-	// Context-level checks (canceled, timed out).
+	if list := classifiers.Load(); list != nil {
+		for _, rc := range *list {
+			if category := rc.classifier.ClassifyError(err); category != "" {
+				return category
+			}
+		}
+	}
+
+	// Default or unknown.
+	return "unknown"
+}
+
+// classifySelfReportedError returns the sanitized category of the first error in
+// the chain of err that implements MetricErrorTyper.
+func classifySelfReportedError(err error) string {
+	var category string
+	findInChain(err, func(e error) bool {
+		if typer, ok := e.(MetricErrorTyper); ok {
+			category = sanitizeErrorType(typer.MetricErrorType())
+		}
+		return category != ""
+	})
+	return category
+}
+
+// findInChain calls match for err and the errors it wraps, as unwrapped by
+// errors.Unwrap and errors.Join, depth first, until match returns true. It
+// reports whether any call did.
+func findInChain(err error, match func(error) bool) bool {
+	if err == nil {
+		return false
+	}
+	if match(err) {
+		return true
+	}
+
+	switch x := err.(type) {
+	case interface{ Unwrap() error }:
+		return findInChain(x.Unwrap(), match)
+	case interface{ Unwrap() []error }:
+		for _, inner := range x.Unwrap() {
+			if findInChain(inner, match) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sanitizeErrorType restricts a self-reported category to a bounded charset, so
+// that it cannot produce unbounded or invalid attribute values.
+func sanitizeErrorType(category string) string {
+	category = strings.TrimSpace(category)
+	if len(category) > maxErrorTypeLen {
+		category = category[:maxErrorTypeLen]
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '.', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, category)
+}
+
+// classifyContextError classifies context-level errors (canceled, timed out).
+func classifyContextError(err error) string {
 	switch {
 	case errors.Is(err, context.Canceled):
 		return "canceled"
 	case errors.Is(err, context.DeadlineExceeded):
 		return "timeout"
 	}
+	return ""
+}
+
+// Network error categories. These labels are stable; errors that match none of
+// the specific categories are recorded as "network_timeout" or "network".
+const (
+	netErrConnRefused     = "network_connection_refused" // ECONNREFUSED: nothing listening
+	netErrConnReset       = "network_connection_reset"   // ECONNRESET, ECONNABORTED: closed by the peer
+	netErrBrokenPipe      = "network_broken_pipe"        // EPIPE: write after the peer closed
+	netErrHostUnreachable = "network_host_unreachable"   // EHOSTUNREACH, ENETUNREACH: no route
+	netErrTimeout         = "network_timeout"            // any network error that timed out
+	netErrOther           = "network"                    // any other network error
+	dnsErrNotFound        = "dns_not_found"              // the host does not exist (NXDOMAIN)
+	dnsErrTemporary       = "dns_temporary"              // lookup timed out or server failure
+	dnsErrOther           = "dns_error"                  // any other DNS error
+	tlsErrHandshake       = "tls_handshake"              // the TLS handshake failed
+	tlsErrCertificate     = "tls_certificate"            // the peer's certificate was rejected
+)
+
+// classifyNetworkError classifies network, DNS and TLS errors. A *url.Error, as
+// returned by http.Client, is classified by the error it wraps.
+func classifyNetworkError(err error) string {
+	// A *url.Error implements net.Error itself, whatever the failure was.
+	for {
+		var urlErr *url.Error
+		if !errors.As(err, &urlErr) || urlErr.Err == nil {
+			break
+		}
+		err = urlErr.Err
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		switch {
+		case dnsErr.IsNotFound:
+			return dnsErrNotFound
+		case dnsErr.IsTimeout, dnsErr.IsTemporary:
+			return dnsErrTemporary
+		default:
+			return dnsErrOther
+		}
+	}
+
+	if category := classifyTLSError(err); category != "" {
+		return category
+	}
+
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return netErrConnRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNABORTED):
+		return netErrConnReset
+	case errors.Is(err, syscall.EPIPE):
+		return netErrBrokenPipe
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return netErrHostUnreachable
+	}
 
-	// Network errors (using net.Error interface).
-	// This categorizes typical transient vs. permanent network issues.
 	var netErr net.Error
 	if errors.As(err, &netErr) {
 		if netErr.Timeout() {
-			return "network_timeout"
+			return netErrTimeout
 		}
-		return "network"
+		return netErrOther
 	}
+	return ""
+}
 
-	// Check for parse/syntax errors.
-	msg := err.Error()
-	if strings.Contains(strings.ToLower(msg), "parse") || strings.Contains(strings.ToLower(msg), "syntax") {
+// classifyTLSError classifies TLS handshake and certificate verification errors.
+func classifyTLSError(err error) string {
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		invalidCert      x509.CertificateInvalidError
+		hostname         x509.HostnameError
+		verification     *tls.CertificateVerificationError
+	)
+	switch {
+	case errors.As(err, &unknownAuthority), errors.As(err, &invalidCert),
+		errors.As(err, &hostname), errors.As(err, &verification):
+		return tlsErrCertificate
+	}
+
+	var (
+		recordHeader tls.RecordHeaderError
+		alert        tls.AlertError
+	)
+	if errors.As(err, &recordHeader) || errors.As(err, &alert) {
+		return tlsErrHandshake
+	}
+	return ""
+}
+
+// classifyInvalidInputError classifies parse and syntax errors.
+func classifyInvalidInputError(err error) string {
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "parse") || strings.Contains(msg, "syntax") {
 		return "invalid_input"
 	}
+	return ""
+}
 
-	//  Check for known PostgreSQL DB errors.
-	var pgErr *pgconn.PgError
-	if errors.As(err, &pgErr) {
-		switch pgErr.Code {
-		case pgerrcode.UniqueViolation:
-			return "db_unique_violation"
-		case pgerrcode.ForeignKeyViolation:
-			return "db_fk_violation"
-		default:
-			return "db_error"
-		}
+// Database error categories. These labels are stable and suitable for alerting;
+// the same label is used for the equivalent error of each database.
+const (
+	dbErrNoRows             = "db_no_rows"              // sql.ErrNoRows
+	dbErrConnDone           = "db_conn_done"            // sql.ErrConnDone
+	dbErrTxDone             = "db_tx_done"              // sql.ErrTxDone
+	dbErrBadConn            = "db_bad_conn"             // driver.ErrBadConn
+	dbErrUniqueViolation    = "db_unique_violation"     // duplicate key
+	dbErrFKViolation        = "db_fk_violation"         // foreign key constraint
+	dbErrNotNullViolation   = "db_not_null_violation"   // NULL in a NOT NULL column
+	dbErrCheckViolation     = "db_check_violation"      // check constraint
+	dbErrConstraint         = "db_constraint_violation" // other integrity constraints
+	dbErrSerialization      = "db_serialization_failure"
+	dbErrDeadlock           = "db_deadlock"
+	dbErrLockTimeout        = "db_lock_timeout" // lock not available, or database busy
+	dbErrTooManyConnections = "db_too_many_connections"
+	dbErrQueryCanceled      = "db_query_canceled" // canceled or statement timeout
+	dbErrConnection         = "db_connection_error"
+	dbErrOther              = "db_error" // any other database error
+)
+
+// classifySQLError classifies the sentinel errors of database/sql.
+func classifySQLError(err error) string {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return dbErrNoRows
+	case errors.Is(err, sql.ErrConnDone):
+		return dbErrConnDone
+	case errors.Is(err, sql.ErrTxDone):
+		return dbErrTxDone
+	case errors.Is(err, driver.ErrBadConn):
+		return dbErrBadConn
+	}
+	return ""
+}
+
+// classifySQLStateError classifies PostgreSQL DB errors by SQLSTATE. Matching on
+// the SQLState method covers the PgError types of both pgconn (pgx v4) and pgx v5.
+func classifySQLStateError(err error) string {
+	var pgErr sqlStateError
+	if !errors.As(err, &pgErr) {
+		return ""
 	}
 
-	// Check for gRPC errors.
+	switch code := pgErr.SQLState(); {
+	case code == pgerrcode.UniqueViolation:
+		return dbErrUniqueViolation
+	case code == pgerrcode.ForeignKeyViolation:
+		return dbErrFKViolation
+	case code == pgerrcode.NotNullViolation:
+		return dbErrNotNullViolation
+	case code == pgerrcode.CheckViolation:
+		return dbErrCheckViolation
+	case pgerrcode.IsIntegrityConstraintViolation(code):
+		return dbErrConstraint
+	case code == pgerrcode.SerializationFailure:
+		return dbErrSerialization
+	case code == pgerrcode.DeadlockDetected:
+		return dbErrDeadlock
+	case code == pgerrcode.LockNotAvailable:
+		return dbErrLockTimeout
+	case code == pgerrcode.TooManyConnections:
+		return dbErrTooManyConnections
+	case code == pgerrcode.QueryCanceled:
+		return dbErrQueryCanceled
+	case pgerrcode.IsConnectionException(code):
+		return dbErrConnection
+	default:
+		return dbErrOther
+	}
+}
+
+// MySQLErrorType returns the database error category for a MySQL or MariaDB
+// server error number, such as the Number of *mysql.MySQLError
+// (github.com/go-sql-driver/mysql). It is used by the mysqlerr package and can
+// be used to classify the errors of other MySQL drivers.
+func MySQLErrorType(number int) string {
+	// See https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html.
+	switch number {
+	case 1062, 1586: // ER_DUP_ENTRY, ER_DUP_ENTRY_WITH_KEY_NAME
+		return dbErrUniqueViolation
+	case 1216, 1217, 1451, 1452: // ER_NO_REFERENCED_ROW, ER_ROW_IS_REFERENCED (and _2)
+		return dbErrFKViolation
+	case 1048: // ER_BAD_NULL_ERROR
+		return dbErrNotNullViolation
+	case 3819: // ER_CHECK_CONSTRAINT_VIOLATED
+		return dbErrCheckViolation
+	case 1213: // ER_LOCK_DEADLOCK
+		return dbErrDeadlock
+	case 1205: // ER_LOCK_WAIT_TIMEOUT
+		return dbErrLockTimeout
+	case 1040, 1203: // ER_CON_COUNT_ERROR, ER_TOO_MANY_USER_CONNECTIONS
+		return dbErrTooManyConnections
+	case 1317, 3024: // ER_QUERY_INTERRUPTED, ER_QUERY_TIMEOUT
+		return dbErrQueryCanceled
+	default:
+		return dbErrOther
+	}
+}
+
+// sqliteCodeError is implemented by SQLite errors that expose their extended
+// result code, such as *sqlite.Error of modernc.org/sqlite.
+type sqliteCodeError interface {
+	error
+	Code() int
+}
+
+// classifySQLiteError classifies SQLite errors by result code. The errors of
+// github.com/mattn/go-sqlite3 only expose their code as a field; they are
+// classified by importing the sqlite3err package.
+func classifySQLiteError(err error) string {
+	var sqliteErr sqliteCodeError
+	if !errors.As(err, &sqliteErr) {
+		return ""
+	}
+	return SQLiteErrorType(sqliteErr.Code())
+}
+
+// SQLiteErrorType returns the database error category for a SQLite primary or
+// extended result code, or "" if code is not a SQLite error code. It is used by
+// the built-in classifier and the sqlite3err package, and can be used to
+// classify the errors of other SQLite drivers.
+func SQLiteErrorType(code int) string {
+	// See https://www.sqlite.org/rescode.html.
+	switch code {
+	case 1555, 2067: // SQLITE_CONSTRAINT_PRIMARYKEY, SQLITE_CONSTRAINT_UNIQUE
+		return dbErrUniqueViolation
+	case 787: // SQLITE_CONSTRAINT_FOREIGNKEY
+		return dbErrFKViolation
+	case 1299: // SQLITE_CONSTRAINT_NOTNULL
+		return dbErrNotNullViolation
+	case 275: // SQLITE_CONSTRAINT_CHECK
+		return dbErrCheckViolation
+	case 517: // SQLITE_BUSY_SNAPSHOT
+		return dbErrSerialization
+	}
+
+	switch code & 0xff {
+	case 19: // SQLITE_CONSTRAINT
+		return dbErrConstraint
+	case 5, 6: // SQLITE_BUSY, SQLITE_LOCKED
+		return dbErrLockTimeout
+	case 9: // SQLITE_INTERRUPT
+		return dbErrQueryCanceled
+	}
+
+	// Primary result codes are 1 (SQLITE_ERROR) to 28 (SQLITE_WARNING); 0 and
+	// 100/101 (SQLITE_ROW/DONE) are not errors.
+	if primary := code & 0xff; primary >= 1 && primary <= 28 {
+		return dbErrOther
+	}
+	return ""
+}
+
+// classifyGRPCError classifies gRPC status errors.
+func classifyGRPCError(err error) string {
 	if s, ok := status.FromError(err); ok {
 		switch s.Code() {
 		case codes.DeadlineExceeded:
@@ -70,7 +505,5 @@ func classifyError(err error) string {
 			return "grpc_" + s.Code().String()
 		}
 	}
-
-	// Default or unknown.
-	return "unknown"
+	return ""
 }
@@ -2,12 +2,26 @@ package metrics
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"database/sql/driver"
 	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgerrcode"
 	"github.com/stretchr/testify/require"
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -22,6 +36,16 @@ func (d dummyNetError) Error() string   { return d.msg }
 func (d dummyNetError) Timeout() bool   { return d.timeout }
 func (d dummyNetError) Temporary() bool { return false }
 
+// sqliteTestError is shaped like *sqlite.Error of modernc.org/sqlite, which
+// exposes its extended result code through a Code method.
+type sqliteTestError struct {
+	msg  string
+	code int
+}
+
+func (e *sqliteTestError) Error() string { return fmt.Sprintf("%s (%d)", e.msg, e.code) }
+func (e *sqliteTestError) Code() int     { return e.code }
+
 func TestClassifyError(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -78,6 +102,76 @@ func TestClassifyError(t *testing.T) {
 			err:      &pgconn.PgError{Code: "99999"},
 			expected: "db_error",
 		},
+		{
+			name:     "pg serialization failure",
+			err:      &pgconn.PgError{Code: pgerrcode.SerializationFailure},
+			expected: "db_serialization_failure",
+		},
+		{
+			name:     "pg deadlock",
+			err:      fmt.Errorf("update stock: %w", &pgconn.PgError{Code: pgerrcode.DeadlockDetected}),
+			expected: "db_deadlock",
+		},
+		{
+			name:     "pg lock not available",
+			err:      &pgconn.PgError{Code: pgerrcode.LockNotAvailable},
+			expected: "db_lock_timeout",
+		},
+		{
+			name:     "pg too many connections",
+			err:      &pgconn.PgError{Code: pgerrcode.TooManyConnections},
+			expected: "db_too_many_connections",
+		},
+		{
+			name:     "pg query canceled",
+			err:      &pgconn.PgError{Code: pgerrcode.QueryCanceled},
+			expected: "db_query_canceled",
+		},
+		{
+			name:     "pg not null violation",
+			err:      &pgconn.PgError{Code: pgerrcode.NotNullViolation},
+			expected: "db_not_null_violation",
+		},
+		{
+			name:     "pg exclusion violation",
+			err:      &pgconn.PgError{Code: pgerrcode.ExclusionViolation},
+			expected: "db_constraint_violation",
+		},
+		{
+			name:     "pg connection failure",
+			err:      &pgconn.PgError{Code: pgerrcode.ConnectionFailure},
+			expected: "db_connection_error",
+		},
+		{
+			name:     "sql no rows",
+			err:      fmt.Errorf("get user: %w", sql.ErrNoRows),
+			expected: "db_no_rows",
+		},
+		{
+			name:     "sql conn done",
+			err:      sql.ErrConnDone,
+			expected: "db_conn_done",
+		},
+		{
+			name:     "sql tx done",
+			err:      sql.ErrTxDone,
+			expected: "db_tx_done",
+		},
+		{
+			name:     "driver bad conn",
+			err:      driver.ErrBadConn,
+			expected: "db_bad_conn",
+		},
+		{
+			name:     "sqlite unique constraint",
+			err:      &sqliteTestError{msg: "constraint failed: UNIQUE constraint failed: users.email", code: 2067},
+			expected: "db_unique_violation",
+		},
+		{
+			name:     "sqlite busy",
+			err:      fmt.Errorf("exec: %w", &sqliteTestError{msg: "database is locked", code: 5}),
+			expected: "db_lock_timeout",
+		},
 		{
 			name:     "grpc deadline exceeded",
 			err:      status.Error(codes.DeadlineExceeded, "deadline exceeded"),
@@ -112,3 +206,305 @@ func TestClassifyError(t *testing.T) {
 		})
 	}
 }
+
+// restoreClassifiers restores the error classifier registry when the test ends.
+func restoreClassifiers(t *testing.T) {
+	t.Helper()
+
+	saved := classifiers.Load()
+	t.Cleanup(func() { classifiers.Store(saved) })
+}
+
+// errDomain is a domain error used to test custom classifiers.
+var errDomain = errors.New("quota exhausted")
+
+func TestRegisterErrorClassifier(t *testing.T) {
+	restoreClassifiers(t)
+
+	// A custom classifier for a domain error, consulted after the built-ins.
+	RegisterErrorClassifier("domain", ErrorClassifierFunc(func(err error) string {
+		if errors.Is(err, errDomain) {
+			return "quota"
+		}
+		return ""
+	}), 0)
+	require.Equal(t, "quota", classifyError(errDomain))
+	require.Equal(t, "timeout", classifyError(context.DeadlineExceeded), "built-ins still apply")
+
+	// A classifier with a higher priority runs before the built-ins.
+	RegisterErrorClassifier("first", ErrorClassifierFunc(func(error) string { return "first" }), 1000)
+	require.Equal(t, "first", classifyError(context.DeadlineExceeded))
+	UnregisterErrorClassifier("first")
+	require.Equal(t, "timeout", classifyError(context.DeadlineExceeded))
+}
+
+func TestRegisterErrorClassifier_OverrideBuiltIn(t *testing.T) {
+	restoreClassifiers(t)
+
+	pgErr := &pgconn.PgError{Code: pgerrcode.UndefinedTable}
+	require.Equal(t, "db_error", classifyError(pgErr))
+
+	// Replace the built-in SQLSTATE rule, keeping its priority.
+	RegisterErrorClassifier(ClassifierSQLState, ErrorClassifierFunc(func(err error) string {
+		var e *pgconn.PgError
+		if errors.As(err, &e) {
+			return "pg_" + e.Code
+		}
+		return ""
+	}), 200)
+	require.Equal(t, "pg_42P01", classifyError(pgErr))
+
+	// Without the parse rule, a parse error is no longer invalid input.
+	UnregisterErrorClassifier(ClassifierInvalidInput)
+	require.Equal(t, "unknown", classifyError(errors.New("failed to parse JSON input")))
+}
+
+func TestRegisterErrorClassifier_FinishDBCall(t *testing.T) {
+	restoreClassifiers(t)
+
+	RegisterErrorClassifier("domain", ErrorClassifierFunc(func(err error) string {
+		if errors.Is(err, errDomain) {
+			return "quota"
+		}
+		return ""
+	}), 0)
+
+	reader := sdkMetric.NewManualReader()
+	dbm, err := NewDBMetrics(sdkMetric.NewMeterProvider(sdkMetric.WithReader(reader)).Meter("test-meter"))
+	require.NoError(t, err)
+	dbm.FinishDBCall(context.Background(), "postgres", "SELECT", "users", errDomain, time.Now())
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "db.calls.errors" {
+				continue
+			}
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			require.True(t, ok)
+			require.Len(t, sum.DataPoints, 1)
+			v, _ := sum.DataPoints[0].Attributes.Value("error_type")
+			require.Equal(t, "quota", v.AsString())
+			return
+		}
+	}
+	require.Fail(t, "db.calls.errors not found")
+}
+
+// typedError is an error that reports its own metric category.
+type typedError struct {
+	category string
+}
+
+func (e typedError) Error() string           { return "failed to parse " + e.category }
+func (e typedError) MetricErrorType() string { return e.category }
+
+func TestClassifyError_SelfReported(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "direct",
+			err:      typedError{"payment_declined"},
+			expected: "payment_declined",
+		},
+		{
+			name:     "takes precedence over heuristics",
+			err:      fmt.Errorf("charge: %w", typedError{"payment_declined"}),
+			expected: "payment_declined",
+		},
+		{
+			name:     "takes precedence over context",
+			err:      fmt.Errorf("%w: %w", context.DeadlineExceeded, typedError{"slow_upstream"}),
+			expected: "slow_upstream",
+		},
+		{
+			name:     "joined",
+			err:      errors.Join(errors.New("first"), fmt.Errorf("second: %w", typedError{"inventory"})),
+			expected: "inventory",
+		},
+		{
+			name:     "empty category falls through",
+			err:      fmt.Errorf("wrapped: %w", errors.Join(typedError{""}, typedError{"fallback"})),
+			expected: "fallback",
+		},
+		{
+			name:     "no category uses heuristics",
+			err:      fmt.Errorf("wrapped: %w", typedError{" "}),
+			expected: "invalid_input",
+		},
+		{
+			name:     "sanitized",
+			err:      typedError{"bad type/with spaces\n"},
+			expected: "bad_type_with_spaces",
+		},
+		{
+			name:     "truncated",
+			err:      typedError{strings.Repeat("x", 100)},
+			expected: strings.Repeat("x", 64),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, classifyError(tt.err))
+		})
+	}
+}
+
+// dialError returns the error of a TCP dial failing with errno, as returned by
+// net.Dial and wrapped by http.Client.
+func dialError(errno syscall.Errno) error {
+	return &url.Error{Op: "Get", URL: "http://payments.internal", Err: &net.OpError{
+		Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", errno),
+	}}
+}
+
+func TestClassifyError_Network(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "connection refused",
+			err:      dialError(syscall.ECONNREFUSED),
+			expected: "network_connection_refused",
+		},
+		{
+			name:     "connection reset",
+			err:      &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)},
+			expected: "network_connection_reset",
+		},
+		{
+			name:     "broken pipe",
+			err:      &net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", syscall.EPIPE)},
+			expected: "network_broken_pipe",
+		},
+		{
+			name:     "host unreachable",
+			err:      dialError(syscall.EHOSTUNREACH),
+			expected: "network_host_unreachable",
+		},
+		{
+			name:     "network unreachable",
+			err:      dialError(syscall.ENETUNREACH),
+			expected: "network_host_unreachable",
+		},
+		{
+			name:     "dial timeout",
+			err:      dialError(syscall.ETIMEDOUT),
+			expected: "network_timeout",
+		},
+		{
+			name:     "dns not found",
+			err:      &url.Error{Op: "Get", URL: "http://nope.invalid", Err: &net.OpError{Op: "dial", Err: &net.DNSError{Name: "nope.invalid", IsNotFound: true}}},
+			expected: "dns_not_found",
+		},
+		{
+			name:     "dns timeout",
+			err:      &net.DNSError{Name: "slow.example", IsTimeout: true},
+			expected: "dns_temporary",
+		},
+		{
+			name:     "dns server failure",
+			err:      &net.DNSError{Name: "flaky.example", IsTemporary: true},
+			expected: "dns_temporary",
+		},
+		{
+			name:     "dns other",
+			err:      &net.DNSError{Name: "odd.example", Err: "no such record type"},
+			expected: "dns_error",
+		},
+		{
+			name:     "tls record header",
+			err:      &url.Error{Op: "Get", URL: "https://plain.example", Err: tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}},
+			expected: "tls_handshake",
+		},
+		{
+			name:     "tls alert",
+			err:      fmt.Errorf("remote error: %w", tls.AlertError(40)),
+			expected: "tls_handshake",
+		},
+		{
+			name:     "unknown authority",
+			err:      &url.Error{Op: "Get", URL: "https://self-signed.example", Err: &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}},
+			expected: "tls_certificate",
+		},
+		{
+			name:     "url error without network cause",
+			err:      &url.Error{Op: "Get", URL: "ftp://example", Err: errors.New("unsupported protocol scheme")},
+			expected: "unknown",
+		},
+		{
+			name:     "url error with context cause",
+			err:      &url.Error{Op: "Get", URL: "http://example", Err: context.Canceled},
+			expected: "canceled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, classifyError(tt.err))
+		})
+	}
+}
+
+func TestClassifyError_DialRefused(t *testing.T) {
+	// Dial a port that was just released, so that nothing is listening.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	_, err = net.Dial("tcp", addr)
+	require.Error(t, err)
+	require.Equal(t, "network_connection_refused", classifyError(err))
+}
+
+func TestSQLiteErrorType(t *testing.T) {
+	tests := []struct {
+		code     int
+		expected string
+	}{
+		{2067, "db_unique_violation"}, // SQLITE_CONSTRAINT_UNIQUE
+		{787, "db_fk_violation"},      // SQLITE_CONSTRAINT_FOREIGNKEY
+		{5, "db_lock_timeout"},        // SQLITE_BUSY
+		{517, "db_serialization_failure"},
+		{19, "db_constraint_violation"},
+		{9, "db_query_canceled"},
+		{1, "db_error"},
+		{101, ""}, // SQLITE_DONE
+		{404, ""}, // not a SQLite result code
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.code), func(t *testing.T) {
+			require.Equal(t, tt.expected, SQLiteErrorType(tt.code))
+		})
+	}
+}
+
+func TestMySQLErrorType(t *testing.T) {
+	tests := []struct {
+		number   int
+		expected string
+	}{
+		{1062, "db_unique_violation"}, // ER_DUP_ENTRY
+		{1452, "db_fk_violation"},     // ER_NO_REFERENCED_ROW_2
+		{1048, "db_not_null_violation"},
+		{3819, "db_check_violation"},
+		{1213, "db_deadlock"},
+		{1205, "db_lock_timeout"},
+		{1040, "db_too_many_connections"},
+		{3024, "db_query_canceled"},
+		{1146, "db_error"}, // ER_NO_SUCH_TABLE
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.number), func(t *testing.T) {
+			require.Equal(t, tt.expected, MySQLErrorType(tt.number))
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package metrics
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Supported push exporters.
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterNone   = "none"
+)
+
+// Supported OTLP transport protocols, named as in the OpenTelemetry specification.
+const (
+	ProtocolGRPC         = "grpc"
+	ProtocolHTTPProtobuf = "http/protobuf"
+)
+
+// Supported export compression algorithms.
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+)
+
+// exporterName returns the configured exporter, defaulting to OTLP when unset.
+func exporterName(cfg Config) string {
+	if cfg.Exporter == "" {
+		return ExporterOTLP
+	}
+	return cfg.Exporter
+}
+
+// otlpProtocol returns the configured protocol, defaulting to gRPC when unset.
+func otlpProtocol(cfg Config) string {
+	if cfg.Protocol == "" {
+		return ProtocolGRPC
+	}
+	return cfg.Protocol
+}
+
+// readerSet holds the metric readers of a pipeline along with the parts
+// that must remain reachable after the MeterProvider has been built.
+type readerSet struct {
+	readers []sdkmetric.Reader
+	push    *swappableExporter
+	promReg *prometheus.Registry
+}
+
+// createReaders creates the metric readers for the configured exporters: a
+// PeriodicReader for the push exporter and, if enabled, a Prometheus reader
+// together with the registry it is registered with.
+func createReaders(ctx context.Context, cfg Config) (readerSet, error) {
+	var rs readerSet
+
+	var producers []sdkmetric.Producer
+	if cfg.RuntimeHistograms {
+		producers = append(producers, NewRuntimeProducer())
+	}
+
+	exporter, err := createPushExporter(ctx, cfg)
+	if err != nil {
+		return readerSet{}, err
+	}
+	if exporter != nil {
+		// Create a PeriodicReader for pushing metrics at intervals. The exporter
+		// is wrapped so that it can be replaced by Reconfigure.
+		rs.push = &swappableExporter{exp: exporter}
+		readerOpts := []sdkmetric.PeriodicReaderOption{sdkmetric.WithInterval(cfg.PushInterval)}
+		if cfg.ExportTimeout > 0 {
+			readerOpts = append(readerOpts, sdkmetric.WithTimeout(cfg.ExportTimeout))
+		}
+		for _, producer := range producers {
+			readerOpts = append(readerOpts, sdkmetric.WithProducer(producer))
+		}
+		rs.readers = append(rs.readers, sdkmetric.NewPeriodicReader(rs.push, readerOpts...))
+	}
+
+	if cfg.Prometheus {
+		var reader sdkmetric.Reader
+		reader, rs.promReg, err = createPrometheusReader(producers...)
+		if err != nil {
+			if rs.push != nil {
+				_ = rs.push.Shutdown(ctx)
+			}
+			return readerSet{}, fmt.Errorf("failed to create Prometheus reader: %w", err)
+		}
+		rs.readers = append(rs.readers, reader)
+	}
+
+	return rs, nil
+}
+
+// createPushExporter creates the configured push exporter, or returns nil if
+// no push exporter is configured.
+func createPushExporter(ctx context.Context, cfg Config) (sdkmetric.Exporter, error) {
+	switch exporterName(cfg) {
+	case ExporterOTLP:
+		exporter, err := createOTLPExporter(ctx, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+		return exporter, nil
+	case ExporterStdout:
+		exporter, err := createStdoutExporter(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout exporter: %w", err)
+		}
+		return exporter, nil
+	default:
+		return nil, nil
+	}
+}
+
+// createOTLPExporter creates an OTLP exporter for the configured protocol.
+func createOTLPExporter(ctx context.Context, cfg Config) (sdkmetric.Exporter, error) {
+	switch otlpProtocol(cfg) {
+	case ProtocolGRPC:
+		return createGRPCExporter(ctx, cfg)
+	case ProtocolHTTPProtobuf:
+		return createHTTPExporter(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q", cfg.Protocol)
+	}
+}
+
+// createGRPCExporter creates an OTLP gRPC exporter with the provided config.
+func createGRPCExporter(ctx context.Context, cfg Config) (sdkmetric.Exporter, error) {
+	opts := []otlpmetricgrpc.Option{
+		otlpmetricgrpc.WithEndpoint(cfg.OTLPEndpoint),
+	}
+	if len(cfg.OTLPHeaders) > 0 {
+		opts = append(opts, otlpmetricgrpc.WithHeaders(cfg.OTLPHeaders))
+	}
+	if cfg.OTLPAuth != nil {
+		opts = append(opts, otlpmetricgrpc.WithDialOption(grpc.WithPerRPCCredentials(grpcAuth{auth: cfg.OTLPAuth})))
+	}
+	if cfg.Compression == CompressionGzip {
+		opts = append(opts, otlpmetricgrpc.WithCompressor(CompressionGzip))
+	}
+	if cfg.ExportTimeout > 0 {
+		opts = append(opts, otlpmetricgrpc.WithTimeout(cfg.ExportTimeout))
+	}
+	if r := cfg.Retry; r != nil {
+		opts = append(opts, otlpmetricgrpc.WithRetry(otlpmetricgrpc.RetryConfig(*r)))
+	}
+
+	// Set up secure or insecure connection.
+	if cfg.OTLPInsecure {
+		opts = append(opts, otlpmetricgrpc.WithTLSCredentials(insecure.NewCredentials()))
+	} else {
+		tlsCfg, err := buildTLSConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, otlpmetricgrpc.WithTLSCredentials(credentials.NewTLS(tlsCfg)))
+	}
+	return otlpmetricgrpc.New(ctx, opts...)
+}
+
+// createHTTPExporter creates an OTLP HTTP/protobuf exporter with the provided config.
+// The endpoint may be a bare "host:port", a "host:port/path" or a full URL; a path,
+// when present, replaces the default "/v1/metrics".
+func createHTTPExporter(ctx context.Context, cfg Config) (sdkmetric.Exporter, error) {
+	host, path, err := splitHTTPEndpoint(cfg.OTLPEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []otlpmetrichttp.Option{
+		otlpmetrichttp.WithEndpoint(host),
+	}
+	if path != "" {
+		opts = append(opts, otlpmetrichttp.WithURLPath(path))
+	}
+	if cfg.Compression == CompressionGzip {
+		opts = append(opts, otlpmetrichttp.WithCompression(otlpmetrichttp.GzipCompression))
+	}
+	if cfg.ExportTimeout > 0 {
+		opts = append(opts, otlpmetrichttp.WithTimeout(cfg.ExportTimeout))
+	}
+	if r := cfg.Retry; r != nil {
+		opts = append(opts, otlpmetrichttp.WithRetry(otlpmetrichttp.RetryConfig(*r)))
+	}
+
+	// Set up secure or insecure connection.
+	if cfg.OTLPInsecure {
+		opts = append(opts, otlpmetrichttp.WithInsecure())
+	} else {
+		tlsCfg, err := buildTLSConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, otlpmetrichttp.WithTLSClientConfig(tlsCfg))
+	}
+
+	// The headers of OTLPAuth override the static headers of the same name.
+	newExporter := func(auth map[string]string) (sdkmetric.Exporter, error) {
+		headers := maps.Clone(cfg.OTLPHeaders)
+		if len(auth) > 0 {
+			if headers == nil {
+				headers = make(map[string]string, len(auth))
+			}
+			maps.Copy(headers, auth)
+		}
+		if len(headers) == 0 {
+			return otlpmetrichttp.New(ctx, opts...)
+		}
+		return otlpmetrichttp.New(ctx, append(slices.Clip(opts), otlpmetrichttp.WithHeaders(headers))...)
+	}
+	if cfg.OTLPAuth != nil {
+		return newHTTPAuthExporter(cfg.OTLPAuth, newExporter)
+	}
+	return newExporter(nil)
+}
+
+// splitHTTPEndpoint splits an HTTP endpoint into its host and URL path.
+// A scheme, if present, is dropped; security is controlled by OTLPInsecure.
+func splitHTTPEndpoint(endpoint string) (host, path string, err error) {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid OTLP HTTP endpoint %q: %w", endpoint, err)
+	}
+	if u.Host == "" {
+		return "", "", fmt.Errorf("invalid OTLP HTTP endpoint %q: missing host", endpoint)
+	}
+	if u.Path == "/" {
+		return u.Host, "", nil
+	}
+	return u.Host, u.Path, nil
+}
+
+// loadCertPool reads a PEM encoded CA bundle into a certificate pool.
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no valid certificates found in " + caFile)
+	}
+	return pool, nil
+}
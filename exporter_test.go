@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestSplitHTTPEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		host     string
+		path     string
+		wantErr  bool
+	}{
+		{name: "host and port", endpoint: "localhost:4318", host: "localhost:4318"},
+		{name: "host with path", endpoint: "collector:4318/otlp/v1/metrics", host: "collector:4318", path: "/otlp/v1/metrics"},
+		{name: "full url", endpoint: "https://collector.example.com/v1/metrics", host: "collector.example.com", path: "/v1/metrics"},
+		{name: "trailing slash", endpoint: "http://collector:4318/", host: "collector:4318"},
+		{name: "missing host", endpoint: "http:///v1/metrics", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			host, path, err := splitHTTPEndpoint(tc.endpoint)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.host, host)
+			require.Equal(t, tc.path, path)
+		})
+	}
+}
+
+func TestCreateOTLPExporter_Protocols(t *testing.T) {
+	ctx := context.Background()
+
+	for _, protocol := range []string{"", ProtocolGRPC, ProtocolHTTPProtobuf} {
+		cfg := NewConfig("localhost:4317", "test-service", "test", WithOTLPProtocol(protocol))
+		exp, err := createOTLPExporter(ctx, cfg)
+		require.NoError(t, err, "protocol %q", protocol)
+		require.NoError(t, exp.Shutdown(ctx))
+	}
+
+	_, err := createOTLPExporter(ctx, NewConfig("localhost:4317", "test-service", "test", WithOTLPProtocol("udp")))
+	require.Error(t, err)
+}
+
+func TestCreateHTTPExporter_CAFile(t *testing.T) {
+	ctx := context.Background()
+
+	// A missing CA file is reported the same way as for gRPC.
+	cfg := NewConfig("localhost:4318", "test-service", "test",
+		WithOTLPProtocol(ProtocolHTTPProtobuf),
+		WithOTLPInsecure(false),
+		WithOTLPCAFile("nonexistent-ca.pem"),
+	)
+	_, err := createOTLPExporter(ctx, cfg)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to load CA file")
+
+	// A file without any PEM certificates is rejected as well.
+	bogus := filepath.Join(t.TempDir(), "bogus.pem")
+	require.NoError(t, os.WriteFile(bogus, []byte("not a certificate"), 0o600))
+	cfg.OTLPCAFile = bogus
+	_, err = createOTLPExporter(ctx, cfg)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no valid certificates")
+}
+
+func TestHTTPExporter_CompressionTimeoutRetry(t *testing.T) {
+	var encodings []string
+	var mu sync.Mutex
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		n := len(encodings)
+		mu.Unlock()
+		if n > 1 {
+			// Stall every export after the first one.
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	cfg := NewConfig(strings.TrimPrefix(srv.URL, "http://"), "test-service", "test",
+		WithOTLPProtocol(ProtocolHTTPProtobuf),
+		WithCompression(CompressionGzip),
+		WithExportTimeout(200*time.Millisecond),
+		WithRetry(RetryConfig{Enabled: false}),
+	)
+	require.NoError(t, validateConfig(cfg))
+
+	ctx := context.Background()
+	exp, err := createOTLPExporter(ctx, cfg)
+	require.NoError(t, err)
+	defer func() { _ = exp.Shutdown(ctx) }()
+
+	// The first export succeeds and is gzip compressed.
+	require.NoError(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+
+	// The second export stalls and is cut off by the export timeout.
+	start := time.Now()
+	require.Error(t, exp.Export(ctx, &metricdata.ResourceMetrics{}))
+	require.Less(t, time.Since(start), 5*time.Second, "expected the export timeout to bound the export")
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, "gzip", encodings[0])
+}
+
+func TestCreateGRPCExporter_CompressionTimeoutRetry(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfig("localhost:4317", "test-service", "test",
+		WithCompression(CompressionGzip),
+		WithExportTimeout(5*time.Second),
+		WithRetry(RetryConfig{
+			Enabled:         true,
+			InitialInterval: time.Second,
+			MaxInterval:     5 * time.Second,
+			MaxElapsedTime:  30 * time.Second,
+		}),
+	)
+	require.NoError(t, validateConfig(cfg))
+
+	exp, err := createOTLPExporter(ctx, cfg)
+	require.NoError(t, err)
+	require.NoError(t, exp.Shutdown(ctx))
+}
+
+func TestValidateConfig_CompressionTimeoutRetry(t *testing.T) {
+	base := func(opts ...Option) Config {
+		return NewConfig("localhost:4317", "test-service", "test", opts...)
+	}
+
+	require.ErrorContains(t, validateConfig(base(WithCompression("zstd"))), "unsupported Compression")
+	require.ErrorContains(t, validateConfig(base(WithExportTimeout(-time.Second))), "ExportTimeout")
+	require.ErrorContains(t, validateConfig(base(WithRetry(RetryConfig{Enabled: true}))), "Retry intervals")
+	require.ErrorContains(t, validateConfig(base(WithRetry(RetryConfig{
+		Enabled:         true,
+		InitialInterval: 10 * time.Second,
+		MaxInterval:     time.Second,
+		MaxElapsedTime:  time.Minute,
+	}))), "MaxInterval")
+
+	// A disabled retry policy needs no intervals.
+	require.NoError(t, validateConfig(base(
+		WithCompression(CompressionNone),
+		WithRetry(RetryConfig{Enabled: false}),
+	)))
+}
@@ -13,11 +13,14 @@ type ExternalMetrics struct {
 	CallsTotal   metric.Int64Counter
 	CallsErrors  metric.Int64Counter
 	CallsLatency metric.Int64Histogram
+	RequestSize  metric.Int64Histogram
+	ResponseSize metric.Int64Histogram
 }
 
 // NewExternalMetrics creates and registers a set of instruments for tracking
 // outbound requests to external services or APIs, including total and error
-// counters and a histogram for call latency. It returns a struct that holds
+// counters, a histogram for call latency and histograms for the request and
+// response body sizes recorded by Transport. It returns a struct that holds
 // references to these instruments.
 func NewExternalMetrics(meter metric.Meter) (*ExternalMetrics, error) {
 	em := &ExternalMetrics{}
@@ -29,7 +32,13 @@ func NewExternalMetrics(meter metric.Meter) (*ExternalMetrics, error) {
 	if em.CallsErrors, err = meter.Int64Counter("external.calls.errors"); err != nil {
 		return nil, err
 	}
-	if em.CallsLatency, err = meter.Int64Histogram("external.calls.duration"); err != nil {
+	if em.CallsLatency, err = meter.Int64Histogram("external.calls.duration", metric.WithUnit("ms")); err != nil {
+		return nil, err
+	}
+	if em.RequestSize, err = meter.Int64Histogram("external.request.size", metric.WithUnit("By")); err != nil {
+		return nil, err
+	}
+	if em.ResponseSize, err = meter.Int64Histogram("external.response.size", metric.WithUnit("By")); err != nil {
 		return nil, err
 	}
 
@@ -53,21 +62,39 @@ func (em *ExternalMetrics) FinishExternalCall(
 	err error,
 	start time.Time,
 ) {
+	var errorType string
 	if err != nil {
+		errorType = classifyError(err)
+	}
+	em.finishCall(ctx, targetService, method, errorType, start)
+}
+
+// httpErrServer is the error_type of outbound HTTP calls answered with a 5xx
+// status code.
+const httpErrServer = "http_server_error"
+
+// finishCall records the latency of an outbound call and, if errorType is not
+// empty, counts it in CallsErrors. Both are keyed by target_service, method and
+// the protocol status attributes, if any, so that FinishExternalCall, Transport
+// and the gRPC client interceptors report the same attribute set.
+func (em *ExternalMetrics) finishCall(
+	ctx context.Context,
+	targetService, method, errorType string,
+	start time.Time,
+	status ...attribute.KeyValue,
+) {
+	attrs := append([]attribute.KeyValue{
+		attribute.String("target_service", targetService),
+		attribute.String("method", method),
+	}, status...)
+
+	if errorType != "" {
 		em.CallsErrors.Add(ctx, 1,
-			metric.WithAttributes(
-				attribute.String("target_service", targetService),
-				attribute.String("method", method),
-				attribute.String("error_type", classifyError(err)),
-			),
+			metric.WithAttributes(append(attrs, attribute.String("error_type", errorType))...),
 		)
 	}
 	elapsedMs := time.Since(start).Milliseconds()
 	em.CallsLatency.Record(ctx, elapsedMs,
-		metric.WithAttributes(
-			attribute.String("target_service", targetService),
-			attribute.String("method", method),
-			attribute.Bool("error", err != nil),
-		),
+		metric.WithAttributes(append(attrs, attribute.Bool("error", errorType != ""))...),
 	)
 }
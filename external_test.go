@@ -14,7 +14,7 @@ import (
 )
 
 func TestExternalMetrics(t *testing.T) {
-	// Reset global state so that 'initialized' is false and 'meterProvider' is nil.
+	// Reset global state so that 'initialized' is false and 'defaultProvider' is nil.
 	metricWrapper.ResetState()
 
 	ctx := context.Background()
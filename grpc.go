@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+)
+
+// GRPCMetrics holds all instruments for gRPC server calls.
+type GRPCMetrics struct {
+	// Synchronous instruments.
+	RequestsTotal    metric.Int64Counter
+	RequestsErrors   metric.Int64Counter
+	RequestsDuration metric.Int64Histogram
+	RequestSize      metric.Int64Histogram
+	ResponseSize     metric.Int64Histogram
+
+	// Asynchronous gauge for concurrency.
+	RequestsInFlight metric.Int64ObservableGauge
+
+	// Atomic for concurrency tracking.
+	inFlight int64
+}
+
+// NewGRPCMetrics creates and registers a set of instruments designed for gRPC
+// server call tracking, including total and error counters, a call duration
+// histogram, histograms for the sizes of received and sent messages, and an
+// asynchronous gauge for in-flight calls. It returns a struct holding references
+// to these instruments, and also registers a callback that periodically captures
+// the current concurrency level.
+func NewGRPCMetrics(meter metric.Meter) (*GRPCMetrics, error) {
+	gm := &GRPCMetrics{}
+	var err error
+
+	// Create synchronous instruments.
+	if gm.RequestsTotal, err = meter.Int64Counter("grpc.requests.total"); err != nil {
+		return nil, err
+	}
+	if gm.RequestsErrors, err = meter.Int64Counter("grpc.requests.errors"); err != nil {
+		return nil, err
+	}
+	if gm.RequestsDuration, err = meter.Int64Histogram("grpc.requests.duration", metric.WithUnit("ms")); err != nil {
+		return nil, err
+	}
+	if gm.RequestSize, err = meter.Int64Histogram("grpc.request.size", metric.WithUnit("By")); err != nil {
+		return nil, err
+	}
+	if gm.ResponseSize, err = meter.Int64Histogram("grpc.response.size", metric.WithUnit("By")); err != nil {
+		return nil, err
+	}
+
+	// Create an asynchronous gauge for concurrency.
+	if gm.RequestsInFlight, err = meter.Int64ObservableGauge("grpc.requests.in_flight"); err != nil {
+		return nil, err
+	}
+
+	// Register a callback that reads the atomic inFlight counter and observes it.
+	_, err = meter.RegisterCallback(
+		func(_ context.Context, obs metric.Observer) error {
+			obs.ObserveInt64(gm.RequestsInFlight, atomic.LoadInt64(&gm.inFlight))
+			return nil
+		},
+		gm.RequestsInFlight,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return gm, nil
+}
+
+// RecordRPCStart increments the total calls counter & concurrency.
+func (gm *GRPCMetrics) RecordRPCStart(ctx context.Context, service, method string) {
+	gm.RequestsTotal.Add(ctx, 1,
+		metric.WithAttributes(
+			attribute.String("rpc.service", service),
+			attribute.String("rpc.method", method),
+		),
+	)
+	atomic.AddInt64(&gm.inFlight, 1)
+}
+
+// RecordRPCEnd decrements concurrency, records errors and latency.
+func (gm *GRPCMetrics) RecordRPCEnd(
+	ctx context.Context,
+	service, method string,
+	code codes.Code,
+	start time.Time,
+) {
+	atomic.AddInt64(&gm.inFlight, -1)
+
+	attrs := metric.WithAttributes(
+		attribute.String("rpc.service", service),
+		attribute.String("rpc.method", method),
+		attribute.Int("rpc.grpc.status_code", int(code)),
+	)
+
+	// Record error if the call did not complete with OK.
+	if code != codes.OK {
+		gm.RequestsErrors.Add(ctx, 1, attrs)
+	}
+
+	// Record call latency as elapsed milliseconds.
+	gm.RequestsDuration.Record(ctx, time.Since(start).Milliseconds(), attrs)
+}
+
+// recordMessageSize records the size of msg in the given histogram, if msg is
+// a protobuf message.
+func recordMessageSize(ctx context.Context, hist metric.Int64Histogram, msg any, attrs ...attribute.KeyValue) {
+	if m, ok := msg.(proto.Message); ok {
+		hist.Record(ctx, int64(proto.Size(m)), metric.WithAttributes(attrs...))
+	}
+}
+
+// splitFullMethod splits a full gRPC method name such as
+// "/helloworld.Greeter/SayHello" into its service and method names.
+func splitFullMethod(fullMethod string) (service, method string) {
+	name := strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
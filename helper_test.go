@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 )
 
@@ -84,3 +85,135 @@ func findIntSumByName(t *testing.T, rm metricdata.ResourceMetrics, name string)
 	require.True(t, found, "metric %q not found in ResourceMetrics", name)
 	return total
 }
+
+// findHistogramSumByName scans the ResourceMetrics for an int64 histogram metric with
+// the given name and returns the sum of all recorded values across its data points.
+func findHistogramSumByName(t *testing.T, rm metricdata.ResourceMetrics, name string) int64 {
+	var total int64
+	found := false
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				hist, ok := m.Data.(metricdata.Histogram[int64])
+				require.True(t, ok, "expected Histogram[int64] for metric %q", name)
+				for _, dp := range hist.DataPoints {
+					total += dp.Sum
+				}
+				found = true
+			}
+		}
+	}
+	require.True(t, found, "histogram metric %q not found", name)
+	return total
+}
+
+// findIntSumByAttr scans through the ResourceMetrics for the Sum[int64] metric with the
+// specified name and sums the values of the data points carrying the given attribute.
+func findIntSumByAttr(t *testing.T, rm metricdata.ResourceMetrics, name string, kv attribute.KeyValue) int64 {
+	var total int64
+	found := false
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				sum, ok := m.Data.(metricdata.Sum[int64])
+				require.True(t, ok, "expected Sum[int64] for metric %q", name)
+				for _, dp := range sum.DataPoints {
+					if v, ok := dp.Attributes.Value(kv.Key); ok && v == kv.Value {
+						total += dp.Value
+					}
+				}
+				found = true
+			}
+		}
+	}
+	require.True(t, found, "metric %q not found in ResourceMetrics", name)
+	return total
+}
+
+// findGaugeValueByAttr scans the ResourceMetrics for an int64 gauge metric with the
+// given name and returns the value of the data point carrying the given attribute.
+func findGaugeValueByAttr(t *testing.T, rm metricdata.ResourceMetrics, name string, kv attribute.KeyValue) int64 {
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			gauge, ok := m.Data.(metricdata.Gauge[int64])
+			require.True(t, ok, "expected Gauge[int64] for metric %q", name)
+			for _, dp := range gauge.DataPoints {
+				if v, ok := dp.Attributes.Value(kv.Key); ok && v == kv.Value {
+					return dp.Value
+				}
+			}
+		}
+	}
+	require.Failf(t, "gauge data point not found", "metric %q with %s=%s", name, kv.Key, kv.Value.Emit())
+	return 0
+}
+
+// findFloatSumByAttr scans through the ResourceMetrics for the Sum[float64] metric with
+// the specified name and sums the values of the data points carrying the given attribute.
+func findFloatSumByAttr(t *testing.T, rm metricdata.ResourceMetrics, name string, kv attribute.KeyValue) float64 {
+	var total float64
+	found := false
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				sum, ok := m.Data.(metricdata.Sum[float64])
+				require.True(t, ok, "expected Sum[float64] for metric %q", name)
+				for _, dp := range sum.DataPoints {
+					if v, ok := dp.Attributes.Value(kv.Key); ok && v == kv.Value {
+						total += dp.Value
+					}
+				}
+				found = true
+			}
+		}
+	}
+	require.True(t, found, "metric %q not found in ResourceMetrics", name)
+	return total
+}
+
+// findFloatSumByName scans through the ResourceMetrics for the Sum[float64] metric
+// with the specified name and sums the values of all its data points.
+func findFloatSumByName(t *testing.T, rm metricdata.ResourceMetrics, name string) float64 {
+	var total float64
+	found := false
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				sum, ok := m.Data.(metricdata.Sum[float64])
+				require.True(t, ok, "expected Sum[float64] for metric %q", name)
+				for _, dp := range sum.DataPoints {
+					total += dp.Value
+				}
+				found = true
+			}
+		}
+	}
+	require.True(t, found, "metric %q not found in ResourceMetrics", name)
+	return total
+}
+
+// hasDataPoints reports whether the ResourceMetrics contain a metric with the
+// given name that has at least one data point.
+func hasDataPoints(rm metricdata.ResourceMetrics, name string) bool {
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			switch data := m.Data.(type) {
+			case metricdata.Gauge[int64]:
+				return len(data.DataPoints) > 0
+			case metricdata.Gauge[float64]:
+				return len(data.DataPoints) > 0
+			case metricdata.Sum[int64]:
+				return len(data.DataPoints) > 0
+			case metricdata.Sum[float64]:
+				return len(data.DataPoints) > 0
+			}
+		}
+	}
+	return false
+}
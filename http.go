@@ -40,10 +40,10 @@ func NewHTTPMetrics(meter metric.Meter) (*HTTPMetrics, error) {
 	if hm.RequestsErrors, err = meter.Int64Counter("requests.errors"); err != nil {
 		return nil, err
 	}
-	if hm.RequestsDuration, err = meter.Int64Histogram("requests.duration"); err != nil {
+	if hm.RequestsDuration, err = meter.Int64Histogram("requests.duration", metric.WithUnit("ms")); err != nil {
 		return nil, err
 	}
-	if hm.ResponseSize, err = meter.Int64Histogram("response.size"); err != nil {
+	if hm.ResponseSize, err = meter.Int64Histogram("response.size", metric.WithUnit("By")); err != nil {
 		return nil, err
 	}
 
@@ -72,12 +72,22 @@ func NewHTTPMetrics(meter metric.Meter) (*HTTPMetrics, error) {
 
 // RecordRequestStart increments the total requests counter & concurrency.
 func (hm *HTTPMetrics) RecordRequestStart(ctx context.Context, method, route string) {
+	hm.countRequest(ctx, method, route)
+	hm.requestStarted()
+}
+
+// countRequest increments the total requests counter.
+func (hm *HTTPMetrics) countRequest(ctx context.Context, method, route string) {
 	hm.RequestsTotal.Add(ctx, 1,
 		metric.WithAttributes(
 			attribute.String("method", method),
 			attribute.String("route", route),
 		),
 	)
+}
+
+// requestStarted increments the number of requests in flight.
+func (hm *HTTPMetrics) requestStarted() {
 	atomic.AddInt64(&hm.inFlight, 1)
 }
 
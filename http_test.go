@@ -13,7 +13,7 @@ import (
 
 // TestHTTPMetrics_Success tests that a successful HTTP request is recorded correctly.
 func TestHTTPMetrics_Success(t *testing.T) {
-	// Reset global state so that 'initialized' is false and 'meterProvider' is nil.
+	// Reset global state so that 'initialized' is false and 'defaultProvider' is nil.
 	metricWrapper.ResetState()
 
 	ctx := context.Background()
@@ -65,7 +65,7 @@ func TestHTTPMetrics_Success(t *testing.T) {
 // TestHTTPMetrics_Error tests that an HTTP request that results in an error
 // records an error count while still recording the duration and response size.
 func TestHTTPMetrics_Error(t *testing.T) {
-	// Reset global state so that 'initialized' is false and 'meterProvider' is nil.
+	// Reset global state so that 'initialized' is false and 'defaultProvider' is nil.
 	metricWrapper.ResetState()
 
 	ctx := context.Background()
@@ -2,32 +2,49 @@ package metrics
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
 	"go.opentelemetry.io/otel/metric"
 
 	apimetric "go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/attribute"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
-	"go.opentelemetry.io/otel/sdk/resource"
 	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
-	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 // Config holds the configuration for the OTLP metrics exporter and MeterProvider.
 type Config struct {
+	Exporter             string
+	Prometheus           bool
+	RuntimeHistograms    bool
+	StdoutWriter         io.Writer
+	StdoutFile           string
+	StdoutPretty         bool
 	OTLPEndpoint         string
+	Protocol             string
 	OTLPInsecure         bool
 	OTLPCAFile           string
+	OTLPClientCertFile   string
+	OTLPClientKeyFile    string
+	OTLPServerName       string
+	TLSConfig            *tls.Config
+	OTLPHeaders          map[string]string
+	OTLPAuth             AuthFunc
+	Compression          string
+	ExportTimeout        time.Duration
+	Retry                *RetryConfig
 	PushInterval         time.Duration
 	ServiceName          string
 	Environment          string
+	ResourceAttributes   map[string]string
 	CustomHistogramViews []InstrumentViewConfig
 }
 
@@ -40,16 +57,101 @@ type InstrumentViewConfig struct {
 	Buckets        []float64
 }
 
-// Global variables for the MeterProvider and shutdown function.
+// RetryConfig holds the retry policy for failed exports. Failed exports are
+// retried with exponential backoff, starting at InitialInterval and capped at
+// MaxInterval, until MaxElapsedTime has passed.
+type RetryConfig struct {
+	Enabled         bool
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	MaxElapsedTime  time.Duration
+}
+
+// Global variables for the default Provider used by the package-level functions.
 var (
-	meterProvider *sdkmetric.MeterProvider
-	shutdownFunc  func(context.Context) error
-	initOnce      sync.Once
-	shutdownOnce  sync.Once
-	initialized   bool
-	mu            sync.RWMutex
+	defaultProvider *Provider
+	initialized     bool
+	mu              sync.RWMutex
 )
 
+// WithOTLPEndpoint sets the OTLP endpoint, e.g. "localhost:4317", or
+// "localhost:4318/v1/metrics" for OTLP/HTTP.
+func WithOTLPEndpoint(endpoint string) Option {
+	return func(cfg *Config) {
+		cfg.OTLPEndpoint = endpoint
+	}
+}
+
+// WithServiceName sets the service.name resource attribute.
+func WithServiceName(serviceName string) Option {
+	return func(cfg *Config) {
+		cfg.ServiceName = serviceName
+	}
+}
+
+// WithEnvironment sets the deployment.environment resource attribute.
+func WithEnvironment(environment string) Option {
+	return func(cfg *Config) {
+		cfg.Environment = environment
+	}
+}
+
+// WithExporter selects the push exporter: ExporterOTLP ("otlp"), ExporterStdout
+// ("stdout") for local development, or ExporterNone ("none") to rely solely on
+// the Prometheus reader.
+func WithExporter(exporter string) Option {
+	return func(cfg *Config) {
+		cfg.Exporter = exporter
+	}
+}
+
+// WithPrometheus enables a Prometheus pull reader alongside the push exporter.
+// Its metrics are served by PrometheusHandler.
+func WithPrometheus(enabled bool) Option {
+	return func(cfg *Config) {
+		cfg.Prometheus = enabled
+	}
+}
+
+// WithRuntimeHistograms registers NewRuntimeProducer on the readers of the
+// pipeline, reporting the GC pause and scheduling latency histograms of the Go
+// runtime.
+func WithRuntimeHistograms(enabled bool) Option {
+	return func(cfg *Config) {
+		cfg.RuntimeHistograms = enabled
+	}
+}
+
+// WithStdoutWriter sets the writer used by the stdout exporter. It defaults to os.Stdout.
+func WithStdoutWriter(w io.Writer) Option {
+	return func(cfg *Config) {
+		cfg.StdoutWriter = w
+	}
+}
+
+// WithStdoutFile makes the stdout exporter append to the given file instead of a writer.
+func WithStdoutFile(path string) Option {
+	return func(cfg *Config) {
+		cfg.StdoutFile = path
+	}
+}
+
+// WithStdoutPretty makes the stdout exporter write indented JSON rather than
+// one JSON document per line.
+func WithStdoutPretty(pretty bool) Option {
+	return func(cfg *Config) {
+		cfg.StdoutPretty = pretty
+	}
+}
+
+// WithOTLPProtocol sets the transport protocol used by the OTLP exporter,
+// either ProtocolGRPC ("grpc") or ProtocolHTTPProtobuf ("http/protobuf").
+func WithOTLPProtocol(protocol string) Option {
+	return func(cfg *Config) {
+		cfg.Protocol = protocol
+	}
+}
+
 // WithPushInterval sets the interval for pushing metrics to the exporter.
 func WithPushInterval(interval time.Duration) Option {
 	return func(cfg *Config) {
@@ -78,73 +180,155 @@ func WithOTLPCAFile(caFile string) Option {
 	}
 }
 
-// InitMetrics configures an OTLP gRPC exporter and sets up the global MeterProvider.
+// WithOTLPClientCert sets the client certificate and key files presented for
+// mutual TLS. The files are re-read when they change, so rotated certificates
+// are picked up without restarting the process.
+func WithOTLPClientCert(certFile, keyFile string) Option {
+	return func(cfg *Config) {
+		cfg.OTLPClientCertFile = certFile
+		cfg.OTLPClientKeyFile = keyFile
+	}
+}
+
+// WithOTLPServerName overrides the server name used to verify the collector's certificate.
+func WithOTLPServerName(serverName string) Option {
+	return func(cfg *Config) {
+		cfg.OTLPServerName = serverName
+	}
+}
+
+// WithTLSConfig sets a base TLS configuration for secure OTLP connections.
+// The CA file, server name and client certificate options are applied on top of it.
+func WithTLSConfig(tlsCfg *tls.Config) Option {
+	return func(cfg *Config) {
+		cfg.TLSConfig = tlsCfg
+	}
+}
+
+// WithOTLPHeaders sets static headers, such as API keys or tenant IDs, sent with every export.
+func WithOTLPHeaders(headers map[string]string) Option {
+	return func(cfg *Config) {
+		cfg.OTLPHeaders = headers
+	}
+}
+
+// WithOTLPAuth sets a hook that supplies headers for every export request.
+// Its headers are sent alongside those set with WithOTLPHeaders, so avoid
+// using the same header name in both.
+func WithOTLPAuth(auth AuthFunc) Option {
+	return func(cfg *Config) {
+		cfg.OTLPAuth = auth
+	}
+}
+
+// WithCompression sets the compression used for export requests,
+// either CompressionGzip ("gzip") or CompressionNone ("none").
+func WithCompression(compression string) Option {
+	return func(cfg *Config) {
+		cfg.Compression = compression
+	}
+}
+
+// WithExportTimeout sets the maximum duration of a single export, including retries.
+func WithExportTimeout(timeout time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.ExportTimeout = timeout
+	}
+}
+
+// WithRetry sets the retry policy for failed exports. Without it, the
+// exporter's default policy is used.
+func WithRetry(retry RetryConfig) Option {
+	return func(cfg *Config) {
+		cfg.Retry = &retry
+	}
+}
+
+// InitMetrics configures the OTLP gRPC or HTTP exporter and/or the Prometheus
+// reader, and sets up the global MeterProvider. It creates the package's default
+// Provider; use NewProvider directly to run independent pipelines.
 func InitMetrics(ctx context.Context, cfg Config) error {
 	if err := validateConfig(cfg); err != nil {
 		return fmt.Errorf("invalid OTLP metrics config: %w", err)
 	}
 
-	var initErr error
-	initOnce.Do(func() {
-		// Create the OTLP exporter.
-		exporter, err := createOTLPExporter(ctx, cfg)
-		if err != nil {
-			initErr = fmt.Errorf("failed to create OTLP exporter: %w", err)
-			return
-		}
+	mu.Lock()
+	defer mu.Unlock()
 
-		// Create a resource to label the service.
-		r, err := resource.New(ctx,
-			resource.WithHost(),
-			resource.WithContainer(),
-			resource.WithAttributes(
-				semconv.ServiceNameKey.String(cfg.ServiceName),
-				semconv.DeploymentEnvironmentKey.String(cfg.Environment),
-			),
-		)
-		if err != nil {
-			initErr = fmt.Errorf("failed to create resource: %w", err)
-			return
-		}
+	// If already initialized, return early
+	if initialized {
+		return nil
+	}
 
-		// Create a PeriodicReader for pushing metrics at intervals.
-		readerOpts := []sdkmetric.PeriodicReaderOption{sdkmetric.WithInterval(cfg.PushInterval)}
-		pr := sdkmetric.NewPeriodicReader(exporter, readerOpts...)
+	p, err := NewProvider(ctx, cfg)
+	if err != nil {
+		return err
+	}
 
-		// Build custom histogram views if provided.
-		customViews := buildCustomViews(cfg.CustomHistogramViews)
+	// Register the global MeterProvider.
+	p.SetGlobal()
 
-		// Build MeterProvider with optional custom views.
-		mp := sdkmetric.NewMeterProvider(
-			sdkmetric.WithReader(pr),
-			sdkmetric.WithResource(r),
-			sdkmetric.WithView(customViews...),
-		)
+	// Mark as initialized.
+	defaultProvider = p
+	initialized = true
+	return nil
+}
 
-		// Register the global MeterProvider.
-		meterProvider = mp
-		apimetric.SetMeterProvider(meterProvider)
+// Reconfigure applies a new Config to the default Provider at runtime, e.g. when
+// credentials rotate or the collector moves. If only the push exporter changes,
+// it is replaced in place and instruments created via GetMeter keep working.
+// Otherwise a new Provider is built and registered globally, and instruments must
+// be recreated from GetMeter. Either way, the old pipeline is flushed first. If
+// metrics are not initialized, Reconfigure behaves like InitMetrics.
+func Reconfigure(ctx context.Context, cfg Config) error {
+	if err := validateConfig(cfg); err != nil {
+		return fmt.Errorf("invalid OTLP metrics config: %w", err)
+	}
 
-		// Define a shutdown function.
-		shutdownFunc = func(shutdownCtx context.Context) error {
-			// flush & stop
-			return mp.Shutdown(shutdownCtx)
-		}
+	mu.Lock()
+	defer mu.Unlock()
 
-		// Mark as initialized.
-		mu.Lock()
+	if !initialized || defaultProvider == nil {
+		p, err := NewProvider(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		p.SetGlobal()
+		defaultProvider = p
 		initialized = true
-		mu.Unlock()
+		return nil
+	}
+
+	// Try to swap the exporter of the running pipeline.
+	err := defaultProvider.Reconfigure(ctx, cfg)
+	if !errors.Is(err, ErrPipelineChanged) {
+		return err
+	}
 
-		log.Printf("[metrics] OTLP metrics initialized. Endpoint=%s Insecure=%v", cfg.OTLPEndpoint, cfg.OTLPInsecure)
-	})
-	return initErr
+	// Build the new pipeline before touching the old one, so a failure leaves
+	// the old pipeline running.
+	p, err := NewProvider(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	old := defaultProvider
+	if err := old.ForceFlush(ctx); err != nil {
+		log.Printf("[metrics] Flush before reconfigure failed: %v", err)
+	}
+	p.SetGlobal()
+	defaultProvider = p
+	if err := old.Shutdown(ctx); err != nil {
+		log.Printf("[metrics] Shutdown of replaced pipeline failed: %v", err)
+	}
+	return nil
 }
 
 // NewConfig creates a new Config with the provided options.
 func NewConfig(endpoint, serviceName, environment string, opts ...Option) Config {
 	c := &Config{
 		OTLPEndpoint:         endpoint,
+		Exporter:             ExporterOTLP,
+		Protocol:             ProtocolGRPC,
 		OTLPInsecure:         true,
 		OTLPCAFile:           "",
 		PushInterval:         10 * time.Second,
@@ -161,6 +345,22 @@ func NewConfig(endpoint, serviceName, environment string, opts ...Option) Config
 	return *c
 }
 
+// buildResourceAttributes returns the resource attributes for the service.
+// ServiceName and Environment take precedence over ResourceAttributes.
+func buildResourceAttributes(cfg Config) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(cfg.ResourceAttributes)+2)
+	for k, v := range cfg.ResourceAttributes {
+		if k == string(semconv.ServiceNameKey) || k == string(semconv.DeploymentEnvironmentKey) {
+			continue
+		}
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	return append(attrs,
+		semconv.ServiceNameKey.String(cfg.ServiceName),
+		semconv.DeploymentEnvironmentKey.String(cfg.Environment),
+	)
+}
+
 // buildCustomViews creates a slice of custom views from the provided config.
 func buildCustomViews(histogramViews []InstrumentViewConfig) []sdkmetric.View {
 	var views []sdkmetric.View
@@ -183,73 +383,79 @@ func buildCustomViews(histogramViews []InstrumentViewConfig) []sdkmetric.View {
 	return views
 }
 
-// createOTLPExporter creates an OTLP gRPC exporter with the provided config.
-func createOTLPExporter(ctx context.Context, cfg Config) (sdkmetric.Exporter, error) {
-	opts := []otlpmetricgrpc.Option{
-		otlpmetricgrpc.WithEndpoint(cfg.OTLPEndpoint),
+// ShutdownMetrics flushes and stops the default Provider.
+// InitMetrics may be called again afterwards.
+func ShutdownMetrics(ctx context.Context) error {
+	mu.Lock()
+	// If not initialized, return early
+	if !initialized {
+		mu.Unlock()
+		return nil
 	}
+	p := defaultProvider
 
-	// Set up secure or insecure connection.
-	if cfg.OTLPInsecure {
-		opts = append(opts, otlpmetricgrpc.WithTLSCredentials(insecure.NewCredentials()))
-	} else {
-		creds, err := credentials.NewClientTLSFromFile(cfg.OTLPCAFile, "")
+	// Mark as uninitialized, so InitMetrics can set up a new pipeline.
+	initialized = false
+	defaultProvider = nil
+	mu.Unlock()
+
+	// Check if environment variable is set to skip flushing, this is intended for testing only.
+	if os.Getenv("METRICS_SKIP_FLUSH") == "1" {
+		log.Printf("[metrics] METRICS_SKIP_FLUSH is set; skipping flush in ShutdownMetrics")
+		return nil
+	}
+
+	var err error
+	if p != nil {
+		err = p.Shutdown(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to load CA file: %w", err)
+			log.Printf("[metrics] Shutdown error: %v", err)
 		}
-		opts = append(opts, otlpmetricgrpc.WithTLSCredentials(creds))
 	}
-	return otlpmetricgrpc.New(ctx, opts...)
+	return err
 }
 
-// ShutdownMetrics flushes and stops the global MeterProvider.
-func ShutdownMetrics(ctx context.Context) error {
-	var err error
-	shutdownOnce.Do(func() {
-		mu.RLock()
-		// If not initialized, return early
-		if !initialized {
-			mu.RUnlock()
-			return
-		}
-		mu.RUnlock()
-
-		// Check if environment variable is set to skip flushing, this is intended for testing only.
-		if os.Getenv("METRICS_SKIP_FLUSH") == "1" {
-			log.Printf("[metrics] METRICS_SKIP_FLUSH is set; skipping flush in ShutdownMetrics")
-			err = nil
-		} else if shutdownFunc != nil {
-			err = shutdownFunc(ctx)
-			if err != nil {
-				log.Printf("[metrics] Shutdown error: %v", err)
-			}
-		}
+// ForceFlush exports all metrics buffered by every reader of the default Provider
+// without shutting it down, returning the joined errors of all readers. It is a
+// no-op before InitMetrics and after ShutdownMetrics.
+func ForceFlush(ctx context.Context) error {
+	mu.RLock()
+	p := defaultProvider
+	ok := initialized
+	mu.RUnlock()
 
-		// Mark as uninitialized.
-		mu.Lock()
-		initialized = false
-		mu.Unlock()
-	})
+	// If not initialized, return early
+	if !ok || p == nil {
+		return nil
+	}
+
+	// Check if environment variable is set to skip flushing, this is intended for testing only.
+	if os.Getenv("METRICS_SKIP_FLUSH") == "1" {
+		log.Printf("[metrics] METRICS_SKIP_FLUSH is set; skipping ForceFlush")
+		return nil
+	}
+
+	err := p.ForceFlush(ctx)
+	if err != nil {
+		log.Printf("[metrics] ForceFlush error: %v", err)
+	}
 	return err
 }
 
-// GetMeter returns a Meter from the global provider or a no-op if uninitialized.
+// GetMeter returns a Meter from the default Provider or a no-op if uninitialized.
 func GetMeter(name string) metric.Meter {
 	mu.RLock()
 	defer mu.RUnlock()
 
-	if !initialized || meterProvider == nil {
+	if !initialized || defaultProvider == nil {
 		return apimetric.GetMeterProvider().Meter(name)
 	}
-	return meterProvider.Meter(name)
+	return defaultProvider.Meter(name)
 }
 
 // validateConfig ensures that mandatory fields in the Config are set,
 // and returns an error if the configuration is invalid.
 func validateConfig(cfg Config) error {
-	if cfg.OTLPEndpoint == "" {
-		return errors.New("OTLPEndpoint is required (e.g. 'localhost:4317')")
-	}
 	if cfg.ServiceName == "" {
 		return errors.New("ServiceName is required")
 	}
@@ -259,8 +465,22 @@ func validateConfig(cfg Config) error {
 	if cfg.PushInterval <= 0 {
 		return errors.New("PushInterval must be greater than 0")
 	}
-	if !cfg.OTLPInsecure && cfg.OTLPCAFile == "" {
-		return errors.New("CA file required for secure mode")
+
+	switch exporterName(cfg) {
+	case ExporterOTLP:
+		if err := validateOTLPConfig(cfg); err != nil {
+			return err
+		}
+	case ExporterStdout:
+		if cfg.StdoutWriter != nil && cfg.StdoutFile != "" {
+			return errors.New("StdoutWriter and StdoutFile are mutually exclusive")
+		}
+	case ExporterNone:
+		if !cfg.Prometheus {
+			return errors.New("no metric reader configured; enable an exporter or Prometheus")
+		}
+	default:
+		return fmt.Errorf("unsupported Exporter %q (expected %q, %q or %q)", cfg.Exporter, ExporterOTLP, ExporterStdout, ExporterNone)
 	}
 
 	// Validate custom histogram views.
@@ -275,3 +495,53 @@ func validateConfig(cfg Config) error {
 
 	return nil
 }
+
+// validateOTLPConfig validates the settings of the OTLP exporter.
+func validateOTLPConfig(cfg Config) error {
+	if cfg.OTLPEndpoint == "" {
+		return errors.New("OTLPEndpoint is required (e.g. 'localhost:4317')")
+	}
+	switch cfg.Protocol {
+	case "", ProtocolGRPC, ProtocolHTTPProtobuf:
+	default:
+		return fmt.Errorf("unsupported Protocol %q (expected %q or %q)", cfg.Protocol, ProtocolGRPC, ProtocolHTTPProtobuf)
+	}
+	if otlpProtocol(cfg) == ProtocolHTTPProtobuf {
+		if _, _, err := splitHTTPEndpoint(cfg.OTLPEndpoint); err != nil {
+			return err
+		}
+	}
+	if !cfg.OTLPInsecure && cfg.OTLPCAFile == "" && cfg.TLSConfig == nil {
+		return errors.New("CA file required for secure mode")
+	}
+	if (cfg.OTLPClientCertFile == "") != (cfg.OTLPClientKeyFile == "") {
+		return errors.New("client certificate and key files must be set together")
+	}
+	if cfg.OTLPInsecure && cfg.OTLPClientCertFile != "" {
+		return errors.New("client certificate requires secure mode")
+	}
+
+	switch cfg.Compression {
+	case "", CompressionNone, CompressionGzip:
+	default:
+		return fmt.Errorf("unsupported Compression %q (expected %q or %q)", cfg.Compression, CompressionGzip, CompressionNone)
+	}
+	if cfg.ExportTimeout < 0 {
+		return errors.New("ExportTimeout must not be negative")
+	}
+	if r := cfg.Retry; r != nil && r.Enabled {
+		if r.InitialInterval <= 0 || r.MaxInterval <= 0 || r.MaxElapsedTime <= 0 {
+			return errors.New("Retry intervals must be greater than 0 when retries are enabled")
+		}
+		if r.MaxInterval < r.InitialInterval {
+			return errors.New("Retry MaxInterval must not be less than InitialInterval")
+		}
+	}
+	for k := range cfg.OTLPHeaders {
+		if strings.TrimSpace(k) == "" {
+			return errors.New("found an OTLPHeader with an empty name")
+		}
+	}
+
+	return nil
+}
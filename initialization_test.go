@@ -2,8 +2,10 @@ package metrics_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -261,6 +263,7 @@ func TestNewConfigDefaults(t *testing.T) {
 	require.Equal(t, 10*time.Second, cfg.PushInterval, "expected default push interval of 10s")
 	require.True(t, cfg.OTLPInsecure, "expected default OTLPInsecure to be true")
 	require.Equal(t, "", cfg.OTLPCAFile, "expected default OTLPCAFile to be empty")
+	require.Equal(t, metricWrapper.ProtocolGRPC, cfg.Protocol, "expected default Protocol to be grpc")
 	require.Nil(t, cfg.CustomHistogramViews, "expected default CustomHistogramViews to be nil")
 }
 
@@ -276,7 +279,7 @@ func TestShutdownMetrics_NotInitialized(t *testing.T) {
 
 // GetMeter returns a Meter from the global (default) provider.
 func TestGetMeter_Uninitialized(t *testing.T) {
-	// Reset global state so that 'initialized' is false and 'meterProvider' is nil.
+	// Reset global state so that 'initialized' is false and 'defaultProvider' is nil.
 	metricWrapper.ResetState()
 
 	// Call GetMeter, which should take the uninitialized branch.
@@ -290,3 +293,143 @@ func TestGetMeter_Uninitialized(t *testing.T) {
 	require.Equal(t, fmt.Sprintf("%T", defaultMeter), fmt.Sprintf("%T", m),
 		"expected GetMeter to return a meter of the same type as the default provider")
 }
+
+func TestInitMetrics_HTTPProtocol(t *testing.T) {
+	// Reset global state so that nothing is initialized.
+	metricWrapper.ResetState()
+
+	ctx := context.Background()
+
+	// Create a config that exports over OTLP/HTTP to a custom path.
+	cfg := metricWrapper.NewConfig(
+		"localhost:4318/otlp/v1/metrics",
+		"test-service",
+		"test",
+		metricWrapper.WithOTLPProtocol(metricWrapper.ProtocolHTTPProtobuf),
+	)
+	require.Equal(t, metricWrapper.ProtocolHTTPProtobuf, cfg.Protocol)
+
+	err := metricWrapper.InitMetrics(ctx, cfg)
+	require.NoError(t, err, "expected no error during InitMetrics")
+
+	err = os.Setenv("METRICS_SKIP_FLUSH", "1")
+	require.NoError(t, err, "expected no error setting environment variable")
+
+	err = metricWrapper.ShutdownMetrics(ctx)
+	require.NoError(t, err, "expected no error during ShutdownMetrics")
+
+	err = os.Unsetenv("METRICS_SKIP_FLUSH")
+	require.NoError(t, err, "expected no error unsetting environment variable")
+}
+
+func TestInitMetrics_InvalidProtocol(t *testing.T) {
+	// Reset global state so that nothing is initialized.
+	metricWrapper.ResetState()
+
+	ctx := context.Background()
+
+	// An unknown protocol should be rejected by validation.
+	cfg := metricWrapper.NewConfig(
+		"localhost:4317",
+		"test-service",
+		"test",
+		metricWrapper.WithOTLPProtocol("http/json"),
+	)
+	err := metricWrapper.InitMetrics(ctx, cfg)
+	require.Error(t, err, "expected error due to unsupported protocol")
+
+	// Reset global state so that nothing is initialized.
+	metricWrapper.ResetState()
+
+	// An HTTP endpoint without a host should be rejected by validation.
+	cfg = metricWrapper.NewConfig(
+		"http:///v1/metrics",
+		"test-service",
+		"test",
+		metricWrapper.WithOTLPProtocol(metricWrapper.ProtocolHTTPProtobuf),
+	)
+	err = metricWrapper.InitMetrics(ctx, cfg)
+	require.Error(t, err, "expected error due to invalid HTTP endpoint")
+}
+
+func TestForceFlush_NotInitialized(t *testing.T) {
+	// Reset global state so that nothing is initialized.
+	metricWrapper.ResetState()
+
+	err := metricWrapper.ForceFlush(context.Background())
+	require.NoError(t, err, "ForceFlush should be a no-op when not initialized")
+}
+
+func TestForceFlush_Checkpoints(t *testing.T) {
+	// Reset global state so that nothing is initialized.
+	metricWrapper.ResetState()
+
+	ctx := context.Background()
+	var out syncBuffer
+
+	cfg := metricWrapper.NewConfig(
+		"",
+		"test-service",
+		"test",
+		metricWrapper.WithExporter(metricWrapper.ExporterStdout),
+		metricWrapper.WithStdoutWriter(&out),
+		metricWrapper.WithPushInterval(1*time.Hour),
+	)
+	err := metricWrapper.InitMetrics(ctx, cfg)
+	require.NoError(t, err, "expected no error during InitMetrics")
+
+	counter, err := metricWrapper.GetMeter("test-meter").Int64Counter("jobs.processed")
+	require.NoError(t, err)
+
+	// Each flush pushes the buffered data while the pipeline keeps running.
+	counter.Add(ctx, 1)
+	require.NoError(t, metricWrapper.ForceFlush(ctx))
+	require.Contains(t, out.String(), `"jobs.processed"`)
+
+	counter.Add(ctx, 1)
+	require.NoError(t, metricWrapper.ForceFlush(ctx))
+	require.Equal(t, 2, strings.Count(out.String(), `"jobs.processed"`))
+
+	err = metricWrapper.ShutdownMetrics(ctx)
+	require.NoError(t, err, "expected no error during ShutdownMetrics")
+
+	// After shutdown, ForceFlush is a no-op again.
+	require.NoError(t, metricWrapper.ForceFlush(ctx))
+}
+
+func TestForceFlush_ReturnsExporterError(t *testing.T) {
+	// Reset global state so that nothing is initialized.
+	metricWrapper.ResetState()
+
+	ctx := context.Background()
+
+	cfg := metricWrapper.NewConfig(
+		"",
+		"test-service",
+		"test",
+		metricWrapper.WithExporter(metricWrapper.ExporterStdout),
+		metricWrapper.WithStdoutWriter(failingWriter{}),
+		metricWrapper.WithPushInterval(1*time.Hour),
+	)
+	err := metricWrapper.InitMetrics(ctx, cfg)
+	require.NoError(t, err, "expected no error during InitMetrics")
+
+	err = metricWrapper.ForceFlush(ctx)
+	require.ErrorContains(t, err, "disk full")
+
+	err = os.Setenv("METRICS_SKIP_FLUSH", "1")
+	require.NoError(t, err, "expected no error setting environment variable")
+
+	err = metricWrapper.ShutdownMetrics(ctx)
+	require.NoError(t, err, "expected no error during ShutdownMetrics")
+
+	err = os.Unsetenv("METRICS_SKIP_FLUSH")
+	require.NoError(t, err, "expected no error unsetting environment variable")
+}
+
+// failingWriter is an io.Writer that always fails.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
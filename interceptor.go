@@ -0,0 +1,248 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor returns a grpc.UnaryServerInterceptor that records every
+// call through RecordRPCStart and RecordRPCEnd, keyed by rpc.service, rpc.method
+// and the returned status code, together with the sizes of the request and
+// response messages. If the handler panics, the call is recorded with
+// codes.Internal before the panic is propagated.
+func (gm *GRPCMetrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (resp any, err error) {
+		start := time.Now()
+		service, method := splitFullMethod(info.FullMethod)
+
+		gm.RecordRPCStart(ctx, service, method)
+		defer func() {
+			if p := recover(); p != nil {
+				gm.RecordRPCEnd(ctx, service, method, codes.Internal, start)
+				panic(p)
+			}
+			gm.RecordRPCEnd(ctx, service, method, status.Code(err), start)
+		}()
+
+		attrs := []attribute.KeyValue{
+			attribute.String("rpc.service", service),
+			attribute.String("rpc.method", method),
+		}
+		recordMessageSize(ctx, gm.RequestSize, req, attrs...)
+		resp, err = handler(ctx, req)
+		if err == nil {
+			recordMessageSize(ctx, gm.ResponseSize, resp, attrs...)
+		}
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns a grpc.StreamServerInterceptor that records
+// every stream like UnaryServerInterceptor, with the size of each received and
+// sent message recorded individually.
+func (gm *GRPCMetrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) (err error) {
+		start := time.Now()
+		ctx := ss.Context()
+		service, method := splitFullMethod(info.FullMethod)
+
+		gm.RecordRPCStart(ctx, service, method)
+		defer func() {
+			if p := recover(); p != nil {
+				gm.RecordRPCEnd(ctx, service, method, codes.Internal, start)
+				panic(p)
+			}
+			gm.RecordRPCEnd(ctx, service, method, status.Code(err), start)
+		}()
+
+		return handler(srv, &serverStream{
+			ServerStream: ss,
+			gm:           gm,
+			attrs: []attribute.KeyValue{
+				attribute.String("rpc.service", service),
+				attribute.String("rpc.method", method),
+			},
+		})
+	}
+}
+
+// serverStream records the sizes of the messages passing through a
+// grpc.ServerStream.
+type serverStream struct {
+	grpc.ServerStream
+	gm    *GRPCMetrics
+	attrs []attribute.KeyValue
+}
+
+// RecvMsg receives a message and records its size.
+func (s *serverStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		recordMessageSize(s.Context(), s.gm.RequestSize, m, s.attrs...)
+	}
+	return err
+}
+
+// SendMsg sends a message and records its size.
+func (s *serverStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		recordMessageSize(s.Context(), s.gm.ResponseSize, m, s.attrs...)
+	}
+	return err
+}
+
+// UnaryClientInterceptor returns a grpc.UnaryClientInterceptor that records every
+// outbound call through RecordExternalCall, with the gRPC service as
+// target_service and the method name as method. The latency is recorded with the
+// returned status code, failed calls are counted in CallsErrors with an
+// error_type attribute derived from the error, and the request and response
+// message sizes are recorded in RequestSize and ResponseSize.
+func (em *ExternalMetrics) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		fullMethod string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		start := time.Now()
+		service, method := splitFullMethod(fullMethod)
+
+		em.RecordExternalCall(ctx, service, method)
+
+		err := invoker(ctx, fullMethod, req, reply, cc, opts...)
+
+		attrs := []attribute.KeyValue{
+			attribute.String("target_service", service),
+			attribute.String("method", method),
+		}
+		recordMessageSize(ctx, em.RequestSize, req, attrs...)
+		if err == nil {
+			recordMessageSize(ctx, em.ResponseSize, reply, attrs...)
+		}
+		em.finishRPC(ctx, service, method, err, start)
+		return err
+	}
+}
+
+// StreamClientInterceptor returns a grpc.StreamClientInterceptor that records
+// every outbound stream like UnaryClientInterceptor, with the size of each sent
+// and received message recorded individually.
+//
+// The stream is recorded as finished once RecvMsg returns an error (io.EOF
+// meaning success), or after the response of a stream without server streaming
+// has been received. Streams that are abandoned before that are not recorded
+// in the latency histogram.
+func (em *ExternalMetrics) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		fullMethod string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		start := time.Now()
+		service, method := splitFullMethod(fullMethod)
+
+		em.RecordExternalCall(ctx, service, method)
+
+		cs, err := streamer(ctx, desc, cc, fullMethod, opts...)
+		if err != nil {
+			em.finishRPC(ctx, service, method, err, start)
+			return nil, err
+		}
+
+		return &clientStream{
+			ClientStream:  cs,
+			serverStreams: desc.ServerStreams,
+			em:            em,
+			attrs: []attribute.KeyValue{
+				attribute.String("target_service", service),
+				attribute.String("method", method),
+			},
+			finish: func(err error) {
+				em.finishRPC(context.WithoutCancel(ctx), service, method, err, start)
+			},
+		}, nil
+	}
+}
+
+// clientStream records the sizes of the messages passing through a
+// grpc.ClientStream, and reports the end of the stream once.
+type clientStream struct {
+	grpc.ClientStream
+	serverStreams bool
+	em            *ExternalMetrics
+	attrs         []attribute.KeyValue
+
+	once   sync.Once
+	finish func(err error)
+}
+
+// SendMsg sends a message and records its size.
+func (s *clientStream) SendMsg(m any) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		recordMessageSize(s.Context(), s.em.RequestSize, m, s.attrs...)
+	}
+	return err
+}
+
+// RecvMsg receives a message and records its size, reporting the end of the
+// stream when there are no more messages to receive.
+func (s *clientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == nil:
+		recordMessageSize(s.Context(), s.em.ResponseSize, m, s.attrs...)
+		if !s.serverStreams {
+			s.done(nil)
+		}
+	case err == io.EOF:
+		s.done(nil)
+	default:
+		s.done(err)
+	}
+	return err
+}
+
+// done reports the end of the stream, at most once.
+func (s *clientStream) done(err error) {
+	s.once.Do(func() { s.finish(err) })
+}
+
+// finishRPC records the latency and error status of an outbound gRPC call.
+func (em *ExternalMetrics) finishRPC(
+	ctx context.Context,
+	targetService, method string,
+	err error,
+	start time.Time,
+) {
+	var errorType string
+	if err != nil {
+		errorType = classifyError(err)
+	}
+	em.finishCall(ctx, targetService, method, errorType, start,
+		attribute.Int("rpc.grpc.status_code", int(status.Code(err))),
+	)
+}
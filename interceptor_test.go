@@ -0,0 +1,130 @@
+package metrics_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	metricWrapper "github.com/janduursma/otel-metrics-wrapper-go"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestGRPC starts an instrumented health server on an in-process listener
+// and returns an instrumented client for it, together with the readers of the
+// server and client metrics.
+func newTestGRPC(t *testing.T) (healthpb.HealthClient, *sdkMetric.ManualReader, *sdkMetric.ManualReader) {
+	t.Helper()
+
+	serverReader := sdkMetric.NewManualReader()
+	gm, err := metricWrapper.NewGRPCMetrics(sdkMetric.NewMeterProvider(sdkMetric.WithReader(serverReader)).Meter("test-meter"))
+	require.NoError(t, err, "failed to create GRPCMetrics")
+	em, clientReader := newTestExternalMetrics(t)
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(gm.UnaryServerInterceptor()),
+		grpc.StreamInterceptor(gm.StreamServerInterceptor()),
+	)
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(em.UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(em.StreamClientInterceptor()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return healthpb.NewHealthClient(conn), serverReader, clientReader
+}
+
+func TestInterceptors_Unary(t *testing.T) {
+	client, serverReader, clientReader := newTestGRPC(t)
+
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	// An unknown service is reported as NotFound.
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "missing"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	rm := collect(t, serverReader)
+	require.EqualValues(t, 2, findIntSumByAttr(t, rm, "grpc.requests.total", attribute.String("rpc.service", "grpc.health.v1.Health")))
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "grpc.requests.errors", attribute.Int("rpc.grpc.status_code", int(codes.NotFound))))
+	require.EqualValues(t, 2, findHistogramCountByName(t, rm, "grpc.requests.duration"))
+	require.EqualValues(t, 2, findHistogramCountByName(t, rm, "grpc.request.size"))
+	require.EqualValues(t, 1, findHistogramCountByName(t, rm, "grpc.response.size"))
+	require.EqualValues(t, 0, findGaugeValueByName(t, rm, "grpc.requests.in_flight"))
+
+	rm = collect(t, clientReader)
+	require.EqualValues(t, 2, findIntSumByAttr(t, rm, "external.calls.total", attribute.String("method", "Check")))
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "external.calls.errors", attribute.String("error_type", "grpc_not_found")))
+	require.EqualValues(t, 2, findHistogramCountByName(t, rm, "external.calls.duration"))
+	require.EqualValues(t, 1, findHistogramCountByName(t, rm, "external.response.size"))
+}
+
+func TestInterceptors_Stream(t *testing.T) {
+	client, serverReader, clientReader := newTestGRPC(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	// Watch streams until the client goes away.
+	cancel()
+	_, err = stream.Recv()
+	require.Equal(t, codes.Canceled, status.Code(err))
+
+	rm := collect(t, clientReader)
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "external.calls.total", attribute.String("method", "Watch")))
+	require.EqualValues(t, 1, findIntSumByName(t, rm, "external.calls.errors"))
+	require.EqualValues(t, 1, findHistogramCountByName(t, rm, "external.calls.duration"))
+	require.EqualValues(t, 1, findHistogramCountByName(t, rm, "external.request.size"))
+	require.EqualValues(t, 1, findHistogramCountByName(t, rm, "external.response.size"))
+
+	require.Eventually(t, func() bool {
+		rm := collect(t, serverReader)
+		return findGaugeValueByName(t, rm, "grpc.requests.in_flight") == 0
+	}, time.Second, 10*time.Millisecond, "expected the server stream to finish")
+
+	rm = collect(t, serverReader)
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "grpc.requests.total", attribute.String("rpc.method", "Watch")))
+	require.EqualValues(t, 1, findHistogramCountByName(t, rm, "grpc.request.size"))
+	require.EqualValues(t, 1, findHistogramCountByName(t, rm, "grpc.response.size"))
+}
+
+func TestUnaryServerInterceptor_Panic(t *testing.T) {
+	reader := sdkMetric.NewManualReader()
+	gm, err := metricWrapper.NewGRPCMetrics(sdkMetric.NewMeterProvider(sdkMetric.WithReader(reader)).Meter("test-meter"))
+	require.NoError(t, err)
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/orders.Orders/Create"}
+	require.Panics(t, func() {
+		_, _ = gm.UnaryServerInterceptor()(context.Background(), nil, info, func(context.Context, any) (any, error) {
+			panic("boom")
+		})
+	})
+
+	rm := collect(t, reader)
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "grpc.requests.errors", attribute.Int("rpc.grpc.status_code", int(codes.Internal))))
+	require.EqualValues(t, 0, findGaugeValueByName(t, rm, "grpc.requests.in_flight"))
+}
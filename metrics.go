@@ -15,19 +15,66 @@ import (
 // Metrics is the top-level struct that holds all categories
 // of metrics in a service. Each sub-struct focuses on a category.
 type Metrics struct {
-	HTTP     *HTTPMetrics
-	DB       *DBMetrics
-	External *ExternalMetrics
-	Runtime  *RuntimeMetrics
+	HTTP      *HTTPMetrics
+	DB        *DBMetrics
+	External  *ExternalMetrics
+	GRPC      *GRPCMetrics
+	Runtime   *RuntimeMetrics
+	Process   *ProcessMetrics
+	Container *ContainerMetrics
 }
 
-// NewMetrics constructs all sub-structs and registers
-// asynchronous instruments/callbacks with the given Meter.
-func NewMetrics(meter metric.Meter) (*Metrics, error) {
+// MetricsOption configures NewMetrics.
+type MetricsOption func(*metricsConfig)
+
+// metricsConfig holds the optional metric sets created by NewMetrics.
+type metricsConfig struct {
+	grpc          bool
+	process       bool
+	processOpts   []ProcessOption
+	container     bool
+	containerOpts []ContainerOption
+}
+
+// WithGRPCMetrics makes NewMetrics create the GRPC metrics.
+func WithGRPCMetrics() MetricsOption {
+	return func(cfg *metricsConfig) {
+		cfg.grpc = true
+	}
+}
+
+// WithProcessMetrics makes NewMetrics create the Process metrics with the
+// given options.
+func WithProcessMetrics(opts ...ProcessOption) MetricsOption {
+	return func(cfg *metricsConfig) {
+		cfg.process = true
+		cfg.processOpts = opts
+	}
+}
+
+// WithContainerMetrics makes NewMetrics create the Container metrics with the
+// given options.
+func WithContainerMetrics(opts ...ContainerOption) MetricsOption {
+	return func(cfg *metricsConfig) {
+		cfg.container = true
+		cfg.containerOpts = opts
+	}
+}
+
+// NewMetrics constructs the HTTP, DB, External and Runtime sub-structs and
+// registers asynchronous instruments/callbacks with the given Meter. The GRPC,
+// Process and Container metrics are only created when enabled with
+// WithGRPCMetrics, WithProcessMetrics and WithContainerMetrics; otherwise
+// their fields are nil.
+func NewMetrics(meter metric.Meter, opts ...MetricsOption) (*Metrics, error) {
 	var (
 		am  Metrics
+		cfg metricsConfig
 		err error
 	)
+	for _, opt := range opts {
+		opt(&cfg)
+	}
 
 	// Create HTTP metrics
 	am.HTTP, err = NewHTTPMetrics(meter)
@@ -47,12 +94,36 @@ func NewMetrics(meter metric.Meter) (*Metrics, error) {
 		return nil, err
 	}
 
+	// Create gRPC metrics
+	if cfg.grpc {
+		am.GRPC, err = NewGRPCMetrics(meter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Create Runtime metrics
 	am.Runtime, err = NewRuntimeMetrics(meter)
 	if err != nil {
 		return nil, err
 	}
 
+	// Create Process metrics
+	if cfg.process {
+		am.Process, err = NewProcessMetrics(meter, cfg.processOpts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Create Container metrics
+	if cfg.container {
+		am.Container, err = NewContainerMetrics(meter, cfg.containerOpts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	log.Println("[metrics] Successfully created all metric instruments.")
 	return &am, nil
 }
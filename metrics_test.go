@@ -108,6 +108,21 @@ func TestNewMetrics_Success(t *testing.T) {
 	require.NotNil(t, m.DB, "expected non-nil DB metrics")
 	require.NotNil(t, m.External, "expected non-nil External metrics")
 	require.NotNil(t, m.Runtime, "expected non-nil Runtime metrics")
+
+	// The gRPC, Process and Container metrics are opt-in.
+	require.Nil(t, m.GRPC, "expected no gRPC metrics by default")
+	require.Nil(t, m.Process, "expected no Process metrics by default")
+	require.Nil(t, m.Container, "expected no Container metrics by default")
+
+	m, err = metricWrapper.NewMetrics(fm,
+		metricWrapper.WithGRPCMetrics(),
+		metricWrapper.WithProcessMetrics(),
+		metricWrapper.WithContainerMetrics(),
+	)
+	require.NoError(t, err, "expected no error from NewMetrics")
+	require.NotNil(t, m.GRPC, "expected non-nil gRPC metrics")
+	require.NotNil(t, m.Process, "expected non-nil Process metrics")
+	require.NotNil(t, m.Container, "expected non-nil Container metrics")
 }
 
 // TestNewMetrics_HTTPError forces an error in HTTP metrics creation.
@@ -143,6 +158,17 @@ func TestNewMetrics_ExternalError(t *testing.T) {
 	require.Contains(t, err.Error(), "forced error for counter external.calls.total")
 }
 
+// TestNewMetrics_GRPCError forces an error in gRPC metrics creation.
+func TestNewMetrics_GRPCError(t *testing.T) {
+	// Force error on "grpc.requests.total" used in NewGRPCMetrics.
+	meter := noop.NewMeterProvider().Meter("noop")
+	fm := fakeMeter{error: "grpc.requests.total", Meter: meter}
+
+	_, err := metricWrapper.NewMetrics(fm, metricWrapper.WithGRPCMetrics())
+	require.Error(t, err, "expected error when gRPC metrics creation fails")
+	require.Contains(t, err.Error(), "forced error for counter grpc.requests.total")
+}
+
 // TestNewMetrics_RuntimeError forces an error in Runtime metrics creation.
 func TestNewMetrics_RuntimeError(t *testing.T) {
 	// Force error on "go.goroutines" used in NewRuntimeMetrics.
@@ -153,3 +179,25 @@ func TestNewMetrics_RuntimeError(t *testing.T) {
 	require.Error(t, err, "expected error when Runtime metrics creation fails")
 	require.Contains(t, err.Error(), "forced error for observable gauge go.goroutines")
 }
+
+// TestNewMetrics_ProcessError forces an error in Process metrics creation.
+func TestNewMetrics_ProcessError(t *testing.T) {
+	// Force error on "process.memory.rss" used in NewProcessMetrics.
+	meter := noop.NewMeterProvider().Meter("noop")
+	fm := fakeMeter{error: "process.memory.rss", Meter: meter}
+
+	_, err := metricWrapper.NewMetrics(fm, metricWrapper.WithProcessMetrics())
+	require.Error(t, err, "expected error when Process metrics creation fails")
+	require.Contains(t, err.Error(), "forced error for observable gauge process.memory.rss")
+}
+
+// TestNewMetrics_ContainerError forces an error in Container metrics creation.
+func TestNewMetrics_ContainerError(t *testing.T) {
+	// Force error on "container.memory.limit" used in NewContainerMetrics.
+	meter := noop.NewMeterProvider().Meter("noop")
+	fm := fakeMeter{error: "container.memory.limit", Meter: meter}
+
+	_, err := metricWrapper.NewMetrics(fm, metricWrapper.WithContainerMetrics())
+	require.Error(t, err, "expected error when Container metrics creation fails")
+	require.Contains(t, err.Error(), "forced error for observable gauge container.memory.limit")
+}
@@ -0,0 +1,266 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Middleware wraps next so that every request is recorded through
+// RecordRequestStart and RecordRequestEnd, with the status code and response
+// size captured from the ResponseWriter. If next panics, the request is recorded
+// with status 500 before the panic is propagated.
+//
+// The route is resolved once next has returned, so that the pattern set by an
+// http.ServeMux is known. It is taken from the RouteResolver set with
+// WithRouteResolver, then from the ServeMux pattern that matched the request
+// (r.Pattern), and otherwise from the request path with numeric and UUID
+// segments replaced by placeholders (see NormalizePath). When next is an
+// *http.ServeMux, requests it matched no pattern for are reported as
+// "unmatched" (see ServeMuxRouteResolver).
+func (hm *HTTPMetrics) Middleware(next http.Handler, opts ...MiddlewareOption) http.Handler {
+	var cfg middlewareConfig
+	if mux, ok := next.(*http.ServeMux); ok {
+		cfg.mux = mux
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx := r.Context()
+
+		hm.requestStarted()
+
+		rec := &responseRecorder{ResponseWriter: w}
+		defer func() {
+			status := rec.statusCode()
+			p := recover()
+			if p != nil {
+				status = http.StatusInternalServerError
+			}
+			route := cfg.resolveRoute(r)
+			hm.countRequest(ctx, r.Method, route)
+			hm.RecordRequestEnd(ctx, r.Method, route, status, rec.written, start)
+			if p != nil {
+				panic(p)
+			}
+		}()
+
+		next.ServeHTTP(wrapResponseWriter(rec), r)
+	})
+}
+
+// responseRecorder captures the status code and number of bytes written
+// through an http.ResponseWriter.
+type responseRecorder struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+// WriteHeader records the first status code and forwards it.
+func (rec *responseRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+// Write records the number of bytes written, with an implicit 200 status.
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.written += int64(n)
+	return n, err
+}
+
+// Unwrap returns the underlying ResponseWriter for use by http.ResponseController.
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// statusCode returns the recorded status, defaulting to 200 when the handler
+// wrote nothing.
+func (rec *responseRecorder) statusCode() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+	return rec.status
+}
+
+// unwrapper is an http.ResponseWriter that exposes the writer it wraps.
+type unwrapper interface {
+	http.ResponseWriter
+	Unwrap() http.ResponseWriter
+}
+
+// flusher forwards http.Flusher to the underlying writer.
+type flusher struct{ rec *responseRecorder }
+
+func (f flusher) Flush() {
+	if f.rec.status == 0 {
+		f.rec.status = http.StatusOK
+	}
+	f.rec.ResponseWriter.(http.Flusher).Flush()
+}
+
+// hijacker forwards http.Hijacker to the underlying writer.
+type hijacker struct{ rec *responseRecorder }
+
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := h.rec.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil && h.rec.status == 0 {
+		h.rec.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// readerFrom forwards io.ReaderFrom to the underlying writer.
+type readerFrom struct{ rec *responseRecorder }
+
+func (r readerFrom) ReadFrom(src io.Reader) (int64, error) {
+	if r.rec.status == 0 {
+		r.rec.status = http.StatusOK
+	}
+	n, err := r.rec.ResponseWriter.(io.ReaderFrom).ReadFrom(src)
+	r.rec.written += n
+	return n, err
+}
+
+// pusher forwards http.Pusher to the underlying writer.
+type pusher struct{ rec *responseRecorder }
+
+func (p pusher) Push(target string, opts *http.PushOptions) error {
+	return p.rec.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// wrapResponseWriter returns rec extended with exactly the optional interfaces
+// (http.Flusher, http.Hijacker, io.ReaderFrom and http.Pusher) implemented by
+// the writer it wraps, so type assertions by handlers keep working.
+func wrapResponseWriter(rec *responseRecorder) http.ResponseWriter {
+	const (
+		isFlusher = 1 << iota
+		isHijacker
+		isReaderFrom
+		isPusher
+	)
+
+	var mask int
+	if _, ok := rec.ResponseWriter.(http.Flusher); ok {
+		mask |= isFlusher
+	}
+	if _, ok := rec.ResponseWriter.(http.Hijacker); ok {
+		mask |= isHijacker
+	}
+	if _, ok := rec.ResponseWriter.(io.ReaderFrom); ok {
+		mask |= isReaderFrom
+	}
+	if _, ok := rec.ResponseWriter.(http.Pusher); ok {
+		mask |= isPusher
+	}
+
+	f, h, rf, p := flusher{rec}, hijacker{rec}, readerFrom{rec}, pusher{rec}
+
+	switch mask {
+	case isFlusher:
+		return struct {
+			unwrapper
+			http.Flusher
+		}{rec, f}
+	case isHijacker:
+		return struct {
+			unwrapper
+			http.Hijacker
+		}{rec, h}
+	case isFlusher | isHijacker:
+		return struct {
+			unwrapper
+			http.Flusher
+			http.Hijacker
+		}{rec, f, h}
+	case isReaderFrom:
+		return struct {
+			unwrapper
+			io.ReaderFrom
+		}{rec, rf}
+	case isFlusher | isReaderFrom:
+		return struct {
+			unwrapper
+			http.Flusher
+			io.ReaderFrom
+		}{rec, f, rf}
+	case isHijacker | isReaderFrom:
+		return struct {
+			unwrapper
+			http.Hijacker
+			io.ReaderFrom
+		}{rec, h, rf}
+	case isFlusher | isHijacker | isReaderFrom:
+		return struct {
+			unwrapper
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+		}{rec, f, h, rf}
+	case isPusher:
+		return struct {
+			unwrapper
+			http.Pusher
+		}{rec, p}
+	case isFlusher | isPusher:
+		return struct {
+			unwrapper
+			http.Flusher
+			http.Pusher
+		}{rec, f, p}
+	case isHijacker | isPusher:
+		return struct {
+			unwrapper
+			http.Hijacker
+			http.Pusher
+		}{rec, h, p}
+	case isFlusher | isHijacker | isPusher:
+		return struct {
+			unwrapper
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{rec, f, h, p}
+	case isReaderFrom | isPusher:
+		return struct {
+			unwrapper
+			io.ReaderFrom
+			http.Pusher
+		}{rec, rf, p}
+	case isFlusher | isReaderFrom | isPusher:
+		return struct {
+			unwrapper
+			http.Flusher
+			io.ReaderFrom
+			http.Pusher
+		}{rec, f, rf, p}
+	case isHijacker | isReaderFrom | isPusher:
+		return struct {
+			unwrapper
+			http.Hijacker
+			io.ReaderFrom
+			http.Pusher
+		}{rec, h, rf, p}
+	case isFlusher | isHijacker | isReaderFrom | isPusher:
+		return struct {
+			unwrapper
+			http.Flusher
+			http.Hijacker
+			io.ReaderFrom
+			http.Pusher
+		}{rec, f, h, rf, p}
+	default:
+		return rec
+	}
+}
@@ -0,0 +1,166 @@
+package metrics_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	metricWrapper "github.com/janduursma/otel-metrics-wrapper-go"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// newTestHTTPMetrics returns HTTPMetrics backed by a ManualReader.
+func newTestHTTPMetrics(t *testing.T) (*metricWrapper.HTTPMetrics, *sdkMetric.ManualReader) {
+	t.Helper()
+
+	reader := sdkMetric.NewManualReader()
+	mp := sdkMetric.NewMeterProvider(sdkMetric.WithReader(reader))
+	hm, err := metricWrapper.NewHTTPMetrics(mp.Meter("test-meter"))
+	require.NoError(t, err, "failed to create HTTPMetrics")
+	return hm, reader
+}
+
+// collect forces a metrics collection on the reader.
+func collect(t *testing.T, reader *sdkMetric.ManualReader) metricdata.ResourceMetrics {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm), "failed to collect metrics")
+	return rm
+}
+
+func TestMiddleware_StatusAndSize(t *testing.T) {
+	hm, reader := newTestHTTPMetrics(t)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /users/{id}", hm.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("not found"))
+	})))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	rm := collect(t, reader)
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "requests.total", attribute.String("route", "GET /users/{id}")))
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "requests.errors", attribute.Int("status_code", http.StatusNotFound)))
+	require.EqualValues(t, len("not found"), findHistogramSumByName(t, rm, "response.size"))
+	require.EqualValues(t, 0, findGaugeValueByName(t, rm, "requests.in_flight"))
+}
+
+func TestMiddleware_ImplicitOK(t *testing.T) {
+	hm, reader := newTestHTTPMetrics(t)
+
+	// A handler that writes nothing is recorded as 200 with an empty body.
+	h := hm.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	rm := collect(t, reader)
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "requests.total", attribute.String("route", "/")))
+	require.EqualValues(t, 1, findHistogramCountByName(t, rm, "requests.duration"))
+	require.EqualValues(t, 0, findHistogramSumByName(t, rm, "response.size"))
+}
+
+func TestMiddleware_Panic(t *testing.T) {
+	hm, reader := newTestHTTPMetrics(t)
+
+	h := hm.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+	require.PanicsWithValue(t, "boom", func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+
+	rm := collect(t, reader)
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "requests.errors", attribute.Int("status_code", http.StatusInternalServerError)))
+	require.EqualValues(t, 0, findGaugeValueByName(t, rm, "requests.in_flight"))
+}
+
+func TestMiddleware_PreservesInterfaces(t *testing.T) {
+	hm, reader := newTestHTTPMetrics(t)
+
+	var flusherOK, hijackerOK, readerFromOK, pusherOK bool
+	srv := httptest.NewServer(hm.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, flusherOK = w.(http.Flusher)
+		_, hijackerOK = w.(http.Hijacker)
+		_, readerFromOK = w.(io.ReaderFrom)
+		_, pusherOK = w.(http.Pusher)
+
+		// io.Copy uses ReadFrom, which must still be counted.
+		_, _ = io.Copy(w, strings.NewReader("streamed body"))
+		w.(http.Flusher).Flush()
+	})))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, "streamed body", string(body))
+
+	// An HTTP/1.1 server response supports all but server push.
+	require.True(t, flusherOK)
+	require.True(t, hijackerOK)
+	require.True(t, readerFromOK)
+	require.False(t, pusherOK)
+
+	rm := collect(t, reader)
+	require.EqualValues(t, len("streamed body"), findHistogramSumByName(t, rm, "response.size"))
+
+	// A writer that lacks optional interfaces is not given them.
+	h := hm.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, hijackerOK = w.(http.Hijacker)
+		_, pusherOK = w.(http.Pusher)
+		_, flusherOK = w.(http.Flusher)
+
+		// http.ResponseController still reaches the underlying writer.
+		require.NoError(t, http.NewResponseController(w).Flush())
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	require.True(t, flusherOK)
+	require.False(t, hijackerOK)
+	require.False(t, pusherOK)
+}
+
+// pushWriter is a ResponseWriter that supports server push and hijacking.
+type pushWriter struct {
+	*httptest.ResponseRecorder
+	pushed []string
+}
+
+func (p *pushWriter) Push(target string, _ *http.PushOptions) error {
+	p.pushed = append(p.pushed, target)
+	return nil
+}
+
+func (p *pushWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, http.ErrNotSupported
+}
+
+func TestMiddleware_PusherAndHijacker(t *testing.T) {
+	hm, _ := newTestHTTPMetrics(t)
+
+	pw := &pushWriter{ResponseRecorder: httptest.NewRecorder()}
+	h := hm.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		p, ok := w.(http.Pusher)
+		require.True(t, ok, "expected the wrapped writer to implement http.Pusher")
+		require.NoError(t, p.Push("/style.css", nil))
+
+		hj, ok := w.(http.Hijacker)
+		require.True(t, ok, "expected the wrapped writer to implement http.Hijacker")
+		_, _, err := hj.Hijack()
+		require.ErrorIs(t, err, http.ErrNotSupported)
+	}))
+	h.ServeHTTP(pw, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, []string{"/style.css"}, pw.pushed)
+}
@@ -0,0 +1,30 @@
+// Package mysqlerr classifies the errors of github.com/go-sql-driver/mysql by
+// error number. Importing it registers the classifier under
+// metrics.ClassifierMySQL:
+//
+//	import _ "github.com/janduursma/otel-metrics-wrapper-go/mysqlerr"
+//
+// It is a separate package as importing the driver registers it with
+// database/sql.
+package mysqlerr
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+	metrics "github.com/janduursma/otel-metrics-wrapper-go"
+)
+
+func init() {
+	metrics.RegisterErrorClassifier(metrics.ClassifierMySQL, metrics.ErrorClassifierFunc(ClassifyError), 200)
+}
+
+// ClassifyError returns the database error category of the first
+// *mysql.MySQLError in the chain of err, or "" if there is none.
+func ClassifyError(err error) string {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return ""
+	}
+	return metrics.MySQLErrorType(int(mysqlErr.Number))
+}
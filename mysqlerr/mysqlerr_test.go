@@ -0,0 +1,51 @@
+package mysqlerr_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/janduursma/otel-metrics-wrapper-go/mysqlerr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "duplicate entry",
+			err:      &mysql.MySQLError{Number: 1062, SQLState: [5]byte{'2', '3', '0', '0', '0'}, Message: "Duplicate entry '1' for key 'PRIMARY'"},
+			expected: "db_unique_violation",
+		},
+		{
+			name:     "wrapped deadlock",
+			err:      fmt.Errorf("tx: %w", &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}),
+			expected: "db_deadlock",
+		},
+		{
+			name:     "joined lock wait timeout",
+			err:      errors.Join(errors.New("rollback failed"), &mysql.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}),
+			expected: "db_lock_timeout",
+		},
+		{
+			name:     "other error",
+			err:      &mysql.MySQLError{Number: 1146, Message: "Table 'shop.users' doesn't exist"},
+			expected: "db_error",
+		},
+		{
+			name:     "driver sentinel error",
+			err:      mysql.ErrInvalidConn,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, mysqlerr.ClassifyError(tt.err))
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// pgxDBSystem is the db_system attribute of calls recorded by PgxTracer.
+const pgxDBSystem = "postgres"
+
+// Operations recorded by PgxTracer for calls without SQL text.
+const (
+	opBatch = "BATCH"
+	opCopy  = "COPY"
+)
+
+// PgxTracer returns a tracer for pgx v5 that records every query through
+// RecordDBCall and FinishDBCall with db_system "postgres", the operation and
+// table parsed from the SQL (see WithQueryParser), and the number of rows
+// affected in RowsAffected. Failed queries are counted with an error_type
+// attribute derived from their SQLSTATE code.
+//
+// The returned tracer also implements pgx.BatchTracer, recording each query of
+// a batch individually, and pgx.CopyFromTracer, recording CopyFrom as a "COPY"
+// operation on the target table. Install it on the connection config:
+//
+//	cfg.ConnConfig.Tracer = dbMetrics.PgxTracer()
+func (dbm *DBMetrics) PgxTracer(opts ...DriverOption) pgx.QueryTracer {
+	return &pgxTracer{rec: newSQLRecorder(dbm, pgxDBSystem, opts)}
+}
+
+// pgxTracer is the tracer returned by DBMetrics.PgxTracer.
+type pgxTracer struct {
+	rec *sqlRecorder
+}
+
+// pgxCall is a query or CopyFrom in progress, stored in its context.
+type pgxCall struct {
+	start            time.Time
+	operation, table string
+}
+
+// pgxBatch is a batch in progress, stored in its context.
+type pgxBatch struct {
+	// last is the time the previous query of the batch completed.
+	last    time.Time
+	queries int
+}
+
+// Context keys for calls and batches in progress.
+type (
+	pgxCallKey  struct{}
+	pgxBatchKey struct{}
+)
+
+// TraceQueryStart implements pgx.QueryTracer.
+func (t *pgxTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	operation, table := t.rec.cfg.parseQuery(data.SQL)
+	return t.start(ctx, operation, table)
+}
+
+// TraceQueryEnd implements pgx.QueryTracer.
+func (t *pgxTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	t.end(ctx, data.CommandTag, data.Err)
+}
+
+// TraceBatchStart implements pgx.BatchTracer.
+func (t *pgxTracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceBatchStartData) context.Context {
+	return context.WithValue(ctx, pgxBatchKey{}, &pgxBatch{last: time.Now()})
+}
+
+// TraceBatchQuery implements pgx.BatchTracer. The duration of a query is the
+// time since the previous query of the batch completed.
+func (t *pgxTracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	batch, ok := ctx.Value(pgxBatchKey{}).(*pgxBatch)
+	if !ok {
+		return
+	}
+	operation, table := t.rec.cfg.parseQuery(data.SQL)
+	t.rec.dbm.RecordDBCall(ctx, pgxDBSystem, operation, table)
+	t.finish(ctx, pgxCall{start: batch.last, operation: operation, table: table}, data.CommandTag, data.Err)
+	batch.last = time.Now()
+	batch.queries++
+}
+
+// TraceBatchEnd implements pgx.BatchTracer. A batch that failed before any of
+// its queries was traced is recorded as a "BATCH" operation.
+func (t *pgxTracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	batch, ok := ctx.Value(pgxBatchKey{}).(*pgxBatch)
+	if !ok || batch.queries > 0 || data.Err == nil {
+		return
+	}
+	t.rec.dbm.RecordDBCall(ctx, pgxDBSystem, opBatch, "")
+	t.rec.dbm.FinishDBCall(ctx, pgxDBSystem, opBatch, "", data.Err, batch.last)
+}
+
+// TraceCopyFromStart implements pgx.CopyFromTracer.
+func (t *pgxTracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	return t.start(ctx, opCopy, strings.Join(data.TableName, "."))
+}
+
+// TraceCopyFromEnd implements pgx.CopyFromTracer.
+func (t *pgxTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	t.end(ctx, data.CommandTag, data.Err)
+}
+
+// start records the start of a call and stores it in the returned context.
+func (t *pgxTracer) start(ctx context.Context, operation, table string) context.Context {
+	t.rec.dbm.RecordDBCall(ctx, pgxDBSystem, operation, table)
+	return context.WithValue(ctx, pgxCallKey{}, pgxCall{
+		start:     time.Now(),
+		operation: operation,
+		table:     table,
+	})
+}
+
+// end records the end of the call stored in ctx.
+func (t *pgxTracer) end(ctx context.Context, tag pgconn.CommandTag, err error) {
+	if call, ok := ctx.Value(pgxCallKey{}).(pgxCall); ok {
+		t.finish(ctx, call, tag, err)
+	}
+}
+
+// finish records the latency and error status of call, and the rows affected
+// if it succeeded.
+func (t *pgxTracer) finish(ctx context.Context, call pgxCall, tag pgconn.CommandTag, err error) {
+	t.rec.dbm.FinishDBCall(ctx, pgxDBSystem, call.operation, call.table, err, call.start)
+	if err == nil {
+		t.rec.dbm.recordRowsAffected(ctx, pgxDBSystem, call.operation, call.table, tag.RowsAffected())
+	}
+}
@@ -0,0 +1,77 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	metricWrapper "github.com/janduursma/otel-metrics-wrapper-go"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// newTestPgxTracer returns a PgxTracer backed by a ManualReader.
+func newTestPgxTracer(t *testing.T) (pgx.QueryTracer, *sdkMetric.ManualReader) {
+	t.Helper()
+
+	reader := sdkMetric.NewManualReader()
+	dbm, err := metricWrapper.NewDBMetrics(sdkMetric.NewMeterProvider(sdkMetric.WithReader(reader)).Meter("test-meter"))
+	require.NoError(t, err, "failed to create DBMetrics")
+	return dbm.PgxTracer(), reader
+}
+
+func TestPgxTracer_Query(t *testing.T) {
+	tracer, reader := newTestPgxTracer(t)
+
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "UPDATE users SET name = $1"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("UPDATE 3")})
+
+	ctx = tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "INSERT INTO users (name) VALUES ($1)"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: &pgconn.PgError{Code: pgerrcode.UniqueViolation}})
+
+	rm := collect(t, reader)
+	require.EqualValues(t, 2, findIntSumByAttr(t, rm, "db.calls.total", attribute.String("table", "users")))
+	require.EqualValues(t, 2, findIntSumByAttr(t, rm, "db.calls.total", attribute.String("db_system", "postgres")))
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "db.calls.errors", attribute.String("error_type", "db_unique_violation")))
+	require.EqualValues(t, 2, findHistogramCountByName(t, rm, "db.calls.duration"))
+	require.EqualValues(t, 3, findHistogramSumByName(t, rm, "db.calls.rows_affected"))
+}
+
+func TestPgxTracer_Batch(t *testing.T) {
+	tracer, reader := newTestPgxTracer(t)
+	bt, ok := tracer.(pgx.BatchTracer)
+	require.True(t, ok, "expected the tracer to implement pgx.BatchTracer")
+
+	ctx := bt.TraceBatchStart(context.Background(), nil, pgx.TraceBatchStartData{})
+	bt.TraceBatchQuery(ctx, nil, pgx.TraceBatchQueryData{SQL: "DELETE FROM sessions", CommandTag: pgconn.NewCommandTag("DELETE 2")})
+	bt.TraceBatchQuery(ctx, nil, pgx.TraceBatchQueryData{SQL: "SELECT 1", CommandTag: pgconn.NewCommandTag("SELECT 1")})
+	bt.TraceBatchEnd(ctx, nil, pgx.TraceBatchEndData{})
+
+	// A batch that fails before any query is recorded as a whole.
+	ctx = bt.TraceBatchStart(context.Background(), nil, pgx.TraceBatchStartData{})
+	bt.TraceBatchEnd(ctx, nil, pgx.TraceBatchEndData{Err: errors.New("conn closed")})
+
+	rm := collect(t, reader)
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "db.calls.total", attribute.String("operation", "DELETE")))
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "db.calls.total", attribute.String("operation", "SELECT")))
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "db.calls.errors", attribute.String("operation", "BATCH")))
+	require.EqualValues(t, 3, findHistogramCountByName(t, rm, "db.calls.duration"))
+	require.EqualValues(t, 3, findHistogramSumByName(t, rm, "db.calls.rows_affected"))
+}
+
+func TestPgxTracer_CopyFrom(t *testing.T) {
+	tracer, reader := newTestPgxTracer(t)
+	ct, ok := tracer.(pgx.CopyFromTracer)
+	require.True(t, ok, "expected the tracer to implement pgx.CopyFromTracer")
+
+	ctx := ct.TraceCopyFromStart(context.Background(), nil, pgx.TraceCopyFromStartData{TableName: pgx.Identifier{"public", "events"}})
+	ct.TraceCopyFromEnd(ctx, nil, pgx.TraceCopyFromEndData{CommandTag: pgconn.NewCommandTag("COPY 100")})
+
+	rm := collect(t, reader)
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "db.calls.total", attribute.String("table", "public.events")))
+	require.EqualValues(t, 100, findHistogramSumByName(t, rm, "db.calls.rows_affected"))
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// createPoolInstruments creates the asynchronous instruments observed by ObservePool.
+func (dbm *DBMetrics) createPoolInstruments(meter metric.Meter) error {
+	var err error
+
+	if dbm.PoolOpenConnections, err = meter.Int64ObservableGauge("db.pool.connections.open"); err != nil {
+		return err
+	}
+	if dbm.PoolInUseConnections, err = meter.Int64ObservableGauge("db.pool.connections.in_use"); err != nil {
+		return err
+	}
+	if dbm.PoolIdleConnections, err = meter.Int64ObservableGauge("db.pool.connections.idle"); err != nil {
+		return err
+	}
+	if dbm.PoolMaxOpen, err = meter.Int64ObservableGauge("db.pool.connections.max_open"); err != nil {
+		return err
+	}
+	if dbm.PoolWaitCount, err = meter.Int64ObservableCounter("db.pool.wait.count"); err != nil {
+		return err
+	}
+	if dbm.PoolWaitDuration, err = meter.Int64ObservableCounter("db.pool.wait.duration", metric.WithUnit("ms")); err != nil {
+		return err
+	}
+	if dbm.PoolClosed, err = meter.Int64ObservableCounter("db.pool.connections.closed"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ObservePool registers a callback that reports the statistics of db under the
+// given pool name: the number of open, in-use and idle connections and the
+// maximum number of open connections (0 meaning unlimited), as gauges; and the
+// number of and total time spent waiting for a connection and the number of
+// connections closed because of SetMaxIdleConns ("max_idle"),
+// SetConnMaxIdleTime ("max_idle_time") and SetConnMaxLifetime ("max_lifetime"),
+// as counters with a reason attribute.
+//
+// Call Unregister on the returned registration when db is closed, so that the
+// pool is no longer reported.
+func (dbm *DBMetrics) ObservePool(name string, db *sql.DB) (metric.Registration, error) {
+	pool := metric.WithAttributes(attribute.String("pool", name))
+	closedBy := func(reason string) metric.ObserveOption {
+		return metric.WithAttributes(attribute.String("pool", name), attribute.String("reason", reason))
+	}
+
+	return dbm.meter.RegisterCallback(
+		// This callback will be called once per collection interval.
+		func(_ context.Context, obs metric.Observer) error {
+			stats := db.Stats()
+
+			obs.ObserveInt64(dbm.PoolOpenConnections, int64(stats.OpenConnections), pool)
+			obs.ObserveInt64(dbm.PoolInUseConnections, int64(stats.InUse), pool)
+			obs.ObserveInt64(dbm.PoolIdleConnections, int64(stats.Idle), pool)
+			obs.ObserveInt64(dbm.PoolMaxOpen, int64(stats.MaxOpenConnections), pool)
+			obs.ObserveInt64(dbm.PoolWaitCount, stats.WaitCount, pool)
+			obs.ObserveInt64(dbm.PoolWaitDuration, stats.WaitDuration.Milliseconds(), pool)
+			obs.ObserveInt64(dbm.PoolClosed, stats.MaxIdleClosed, closedBy("max_idle"))
+			obs.ObserveInt64(dbm.PoolClosed, stats.MaxIdleTimeClosed, closedBy("max_idle_time"))
+			obs.ObserveInt64(dbm.PoolClosed, stats.MaxLifetimeClosed, closedBy("max_lifetime"))
+
+			return nil
+		},
+		dbm.PoolOpenConnections, dbm.PoolInUseConnections, dbm.PoolIdleConnections, dbm.PoolMaxOpen,
+		dbm.PoolWaitCount, dbm.PoolWaitDuration, dbm.PoolClosed,
+	)
+}
@@ -0,0 +1,53 @@
+package metrics_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	metricWrapper "github.com/janduursma/otel-metrics-wrapper-go"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+func TestObservePool(t *testing.T) {
+	registerFakeDriver.Do(func() { sql.Register("metrics-fake", fakeDriver{}) })
+
+	reader := sdkMetric.NewManualReader()
+	dbm, err := metricWrapper.NewDBMetrics(sdkMetric.NewMeterProvider(sdkMetric.WithReader(reader)).Meter("test-meter"))
+	require.NoError(t, err, "failed to create DBMetrics")
+
+	db, err := sql.Open("metrics-fake", "")
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	db.SetMaxOpenConns(3)
+
+	reg, err := dbm.ObservePool("primary", db)
+	require.NoError(t, err)
+
+	// Hold one connection so that it is reported as in use.
+	conn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+
+	rm := collect(t, reader)
+	require.EqualValues(t, 1, findGaugeValueByName(t, rm, "db.pool.connections.open"))
+	require.EqualValues(t, 1, findGaugeValueByName(t, rm, "db.pool.connections.in_use"))
+	require.EqualValues(t, 0, findGaugeValueByName(t, rm, "db.pool.connections.idle"))
+	require.EqualValues(t, 3, findGaugeValueByName(t, rm, "db.pool.connections.max_open"))
+	require.EqualValues(t, 0, findIntSumByAttr(t, rm, "db.pool.wait.count", attribute.String("pool", "primary")))
+	require.EqualValues(t, 0, findIntSumByAttr(t, rm, "db.pool.connections.closed", attribute.String("reason", "max_lifetime")))
+
+	require.NoError(t, conn.Close())
+	rm = collect(t, reader)
+	require.EqualValues(t, 1, findGaugeValueByName(t, rm, "db.pool.connections.idle"))
+
+	// Once unregistered, the pool is no longer reported.
+	require.NoError(t, reg.Unregister())
+	rm = collect(t, reader)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			require.NotEqual(t, "db.pool.connections.open", m.Name, "expected no pool metrics after Unregister")
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// defaultProcDir is the procfs directory of the current process.
+	defaultProcDir = "/proc/self"
+
+	// clockTicksPerSecond is the unit of the CPU times in /proc/<pid>/stat
+	// (USER_HZ), which is 100 on all supported Linux architectures.
+	clockTicksPerSecond = 100
+)
+
+// ProcessOption configures NewProcessMetrics.
+type ProcessOption func(*processConfig)
+
+// processConfig holds the settings of ProcessMetrics.
+type processConfig struct {
+	procDir string
+}
+
+// WithProcDir sets the procfs directory of the process to observe. The default
+// is "/proc/self".
+func WithProcDir(dir string) ProcessOption {
+	return func(cfg *processConfig) {
+		cfg.procDir = dir
+	}
+}
+
+// ProcessMetrics holds the asynchronous instruments for operating system level
+// statistics of the process, read from procfs.
+type ProcessMetrics struct {
+	cpuTime         metric.Float64ObservableCounter
+	memoryRSS       metric.Int64ObservableGauge
+	openFDs         metric.Int64ObservableGauge
+	maxFDs          metric.Int64ObservableGauge
+	threads         metric.Int64ObservableGauge
+	contextSwitches metric.Int64ObservableCounter
+
+	procDir string
+}
+
+// NewProcessMetrics creates and registers asynchronous instruments that capture
+// statistics of the process from procfs (on Linux, /proc/self):
+//
+//   - process.cpu.time: CPU time, by state attribute "user" or "system".
+//   - process.memory.rss: resident set size.
+//   - process.fds.open, process.fds.max: open file descriptors and their soft
+//     limit, which is not reported when unlimited.
+//   - process.threads: operating system threads.
+//   - process.context_switches: context switches, by type attribute "voluntary"
+//     or "involuntary".
+//
+// It returns a struct holding references to these instruments, and also
+// registers a callback that the OpenTelemetry SDK periodically invokes to sample
+// their values. Where procfs is not available, e.g. on macOS or Windows, the
+// unavailable values are silently skipped.
+func NewProcessMetrics(meter metric.Meter, opts ...ProcessOption) (*ProcessMetrics, error) {
+	cfg := processConfig{procDir: defaultProcDir}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	pm := &ProcessMetrics{procDir: cfg.procDir}
+	var err error
+
+	if pm.cpuTime, err = meter.Float64ObservableCounter("process.cpu.time", metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if pm.memoryRSS, err = meter.Int64ObservableGauge("process.memory.rss", metric.WithUnit("By")); err != nil {
+		return nil, err
+	}
+	if pm.openFDs, err = meter.Int64ObservableGauge("process.fds.open"); err != nil {
+		return nil, err
+	}
+	if pm.maxFDs, err = meter.Int64ObservableGauge("process.fds.max"); err != nil {
+		return nil, err
+	}
+	if pm.threads, err = meter.Int64ObservableGauge("process.threads"); err != nil {
+		return nil, err
+	}
+	if pm.contextSwitches, err = meter.Int64ObservableCounter("process.context_switches"); err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(pm.procDir); err != nil {
+		log.Printf("[metrics] Process metrics unavailable: %v", err)
+	}
+
+	// Register a single callback for all metrics.
+	_, err = meter.RegisterCallback(
+		pm.observe,
+		pm.cpuTime, pm.memoryRSS, pm.openFDs, pm.maxFDs, pm.threads, pm.contextSwitches,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return pm, nil
+}
+
+// observe is called once per collection interval to sample all metrics. Files
+// that cannot be read or parsed are skipped.
+func (pm *ProcessMetrics) observe(_ context.Context, obs metric.Observer) error {
+	if utime, stime, err := readProcCPUTime(filepath.Join(pm.procDir, "stat")); err == nil {
+		obs.ObserveFloat64(pm.cpuTime, utime, metric.WithAttributes(attribute.String("state", "user")))
+		obs.ObserveFloat64(pm.cpuTime, stime, metric.WithAttributes(attribute.String("state", "system")))
+	}
+
+	if status, err := readProcStatus(filepath.Join(pm.procDir, "status")); err == nil {
+		if rss, ok := status["VmRSS"]; ok {
+			obs.ObserveInt64(pm.memoryRSS, rss)
+		}
+		if threads, ok := status["Threads"]; ok {
+			obs.ObserveInt64(pm.threads, threads)
+		}
+		if n, ok := status["voluntary_ctxt_switches"]; ok {
+			obs.ObserveInt64(pm.contextSwitches, n, metric.WithAttributes(attribute.String("type", "voluntary")))
+		}
+		if n, ok := status["nonvoluntary_ctxt_switches"]; ok {
+			obs.ObserveInt64(pm.contextSwitches, n, metric.WithAttributes(attribute.String("type", "involuntary")))
+		}
+	}
+
+	if fds, err := os.ReadDir(filepath.Join(pm.procDir, "fd")); err == nil {
+		obs.ObserveInt64(pm.openFDs, int64(len(fds)))
+	}
+	// An unlimited limit is not reported, as it has no meaningful value.
+	if limit, err := readProcOpenFilesLimit(filepath.Join(pm.procDir, "limits")); err == nil && limit >= 0 {
+		obs.ObserveInt64(pm.maxFDs, limit)
+	}
+
+	return nil
+}
+
+// readProcCPUTime returns the user and system CPU time in seconds from a
+// /proc/<pid>/stat file.
+func readProcCPUTime(path string) (utime, stime float64, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// The command name may contain spaces and parentheses, so the fields are
+	// counted from the last closing parenthesis, after which field 3 (state)
+	// follows. utime and stime are fields 14 and 15.
+	end := strings.LastIndexByte(string(data), ')')
+	if end < 0 {
+		return 0, 0, fmt.Errorf("malformed %s", path)
+	}
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) < 13 {
+		return 0, 0, fmt.Errorf("malformed %s", path)
+	}
+	user, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	system, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return float64(user) / clockTicksPerSecond, float64(system) / clockTicksPerSecond, nil
+}
+
+// readProcStatus returns the numeric fields of a /proc/<pid>/status file, with
+// sizes in kB converted to bytes.
+func readProcStatus(path string) (map[string]int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	fields := make(map[string]int64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		parts := strings.Fields(value)
+		if len(parts) == 0 {
+			continue
+		}
+		n, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(parts) > 1 && parts[1] == "kB" {
+			n *= 1024
+		}
+		fields[key] = n
+	}
+	return fields, scanner.Err()
+}
+
+// readProcOpenFilesLimit returns the soft limit on open files from a
+// /proc/<pid>/limits file. An unlimited limit is reported as -1.
+func readProcOpenFilesLimit(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		rest, ok := strings.CutPrefix(line, "Max open files")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			break
+		}
+		if fields[0] == "unlimited" {
+			return -1, nil
+		}
+		return strconv.ParseInt(fields[0], 10, 64)
+	}
+	return 0, fmt.Errorf("no open files limit in %s", path)
+}
@@ -0,0 +1,82 @@
+package metrics_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	metricWrapper "github.com/janduursma/otel-metrics-wrapper-go"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// collectProcessMetrics creates ProcessMetrics with the given options and
+// collects them once.
+func collectProcessMetrics(t *testing.T, opts ...metricWrapper.ProcessOption) metricdata.ResourceMetrics {
+	t.Helper()
+
+	reader := sdkMetric.NewManualReader()
+	_, err := metricWrapper.NewProcessMetrics(sdkMetric.NewMeterProvider(sdkMetric.WithReader(reader)).Meter("test-meter"), opts...)
+	require.NoError(t, err, "failed to create ProcessMetrics")
+	return collect(t, reader)
+}
+
+func TestProcessMetrics_Fixture(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		// utime = 250 and stime = 50 clock ticks; the command contains a ") ".
+		"stat": "42 (my) app) S 1 42 42 0 -1 4194304 100 0 0 0 250 50 0 0 20 0 7 0 100 1000 200\n",
+		"status": "Name:\tapp\nVmRSS:\t    2048 kB\nThreads:\t7\n" +
+			"voluntary_ctxt_switches:\t12\nnonvoluntary_ctxt_switches:\t3\n",
+		"limits": "Limit                     Soft Limit           Hard Limit           Units\n" +
+			"Max open files            1024                 4096                 files\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "fd"), 0o700))
+	for _, name := range []string{"0", "1", "2"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "fd", name), nil, 0o600))
+	}
+
+	rm := collectProcessMetrics(t, metricWrapper.WithProcDir(dir))
+	require.EqualValues(t, 2048*1024, findGaugeValueByName(t, rm, "process.memory.rss"))
+	require.EqualValues(t, 7, findGaugeValueByName(t, rm, "process.threads"))
+	require.EqualValues(t, 3, findGaugeValueByName(t, rm, "process.fds.open"))
+	require.EqualValues(t, 1024, findGaugeValueByName(t, rm, "process.fds.max"))
+	require.EqualValues(t, 12, findIntSumByAttr(t, rm, "process.context_switches", attribute.String("type", "voluntary")))
+	require.EqualValues(t, 3, findIntSumByAttr(t, rm, "process.context_switches", attribute.String("type", "involuntary")))
+	require.InDelta(t, 2.5, findFloatSumByAttr(t, rm, "process.cpu.time", attribute.String("state", "user")), 1e-9)
+	require.InDelta(t, 0.5, findFloatSumByAttr(t, rm, "process.cpu.time", attribute.String("state", "system")), 1e-9)
+}
+
+func TestProcessMetrics_UnlimitedFDs(t *testing.T) {
+	dir := t.TempDir()
+	limits := "Limit                     Soft Limit           Hard Limit           Units\n" +
+		"Max open files            unlimited            unlimited            files\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "limits"), []byte(limits), 0o600))
+
+	rm := collectProcessMetrics(t, metricWrapper.WithProcDir(dir))
+	require.False(t, hasDataPoints(rm, "process.fds.max"), "expected no limit when unlimited")
+}
+
+func TestProcessMetrics_Unavailable(t *testing.T) {
+	rm := collectProcessMetrics(t, metricWrapper.WithProcDir(filepath.Join(t.TempDir(), "missing")))
+	for _, sm := range rm.ScopeMetrics {
+		require.Empty(t, sm.Metrics, "expected no process metrics without procfs")
+	}
+}
+
+func TestProcessMetrics_Self(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("procfs is only available on Linux")
+	}
+
+	rm := collectProcessMetrics(t)
+	require.Greater(t, findGaugeValueByName(t, rm, "process.memory.rss"), int64(0))
+	require.GreaterOrEqual(t, findGaugeValueByName(t, rm, "process.threads"), int64(1))
+	require.GreaterOrEqual(t, findGaugeValueByName(t, rm, "process.fds.open"), int64(3))
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// createPrometheusReader creates a Prometheus pull reader registered with a
+// dedicated registry, so the process-wide default registry is left untouched.
+// Instrument names are translated to Prometheus conventions, e.g.
+// "requests.duration" with unit "ms" becomes "requests_duration_milliseconds"
+// and the counter "db.calls.total" becomes "db_calls_total".
+func createPrometheusReader(producers ...sdkmetric.Producer) (sdkmetric.Reader, *prometheus.Registry, error) {
+	reg := prometheus.NewRegistry()
+	opts := []otelprom.Option{otelprom.WithRegisterer(reg)}
+	for _, producer := range producers {
+		opts = append(opts, otelprom.WithProducer(producer))
+	}
+	reader, err := otelprom.New(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reader, reg, nil
+}
+
+// PrometheusHandler returns an http.Handler that serves the collected metrics in
+// the Prometheus text exposition format. The handler can be mounted before
+// InitMetrics is called; until the Prometheus reader is initialized, it
+// responds with 503 Service Unavailable.
+func PrometheusHandler() http.Handler {
+	return prometheusHandler(func() *prometheus.Registry {
+		mu.RLock()
+		defer mu.RUnlock()
+
+		if !initialized || defaultProvider == nil {
+			return nil
+		}
+		return defaultProvider.promReg
+	})
+}
+
+// prometheusHandler serves the registry returned by lookup at request time.
+func prometheusHandler(lookup func() *prometheus.Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reg := lookup()
+		if reg == nil {
+			http.Error(w, "prometheus metrics are not initialized", http.StatusServiceUnavailable)
+			return
+		}
+		promhttp.HandlerFor(reg, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,106 @@
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	metricWrapper "github.com/janduursma/otel-metrics-wrapper-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheusHandler_NotInitialized(t *testing.T) {
+	// Reset global state so that nothing is initialized.
+	metricWrapper.ResetState()
+
+	rec := httptest.NewRecorder()
+	metricWrapper.PrometheusHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestPrometheusHandler_PrometheusOnly(t *testing.T) {
+	// Reset global state so that nothing is initialized.
+	metricWrapper.ResetState()
+
+	ctx := context.Background()
+
+	// No OTLP endpoint is needed when only the Prometheus reader is used.
+	cfg := metricWrapper.NewConfig(
+		"",
+		"test-service",
+		"test",
+		metricWrapper.WithExporter(metricWrapper.ExporterNone),
+		metricWrapper.WithPrometheus(true),
+	)
+	err := metricWrapper.InitMetrics(ctx, cfg)
+	require.NoError(t, err, "expected no error during InitMetrics")
+
+	meter := metricWrapper.GetMeter("test-meter")
+	hm, err := metricWrapper.NewHTTPMetrics(meter)
+	require.NoError(t, err)
+	dbm, err := metricWrapper.NewDBMetrics(meter)
+	require.NoError(t, err)
+
+	start := time.Now()
+	hm.RecordRequestStart(ctx, "GET", "/users")
+	hm.RecordRequestEnd(ctx, "GET", "/users", 200, 42, start)
+	dbm.RecordDBCall(ctx, "postgres", "SELECT", "users")
+	dbm.FinishDBCall(ctx, "postgres", "SELECT", "users", nil, start)
+
+	srv := httptest.NewServer(metricWrapper.PrometheusHandler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	out := string(body)
+
+	// Names and units are translated to Prometheus conventions.
+	require.Contains(t, out, "requests_total{")
+	require.Contains(t, out, "requests_duration_milliseconds_bucket{")
+	require.Contains(t, out, "response_size_bytes_count{")
+	require.Contains(t, out, "db_calls_total{")
+	require.NotContains(t, out, "db_calls_total_total")
+	require.Contains(t, out, `service_name="test-service"`)
+
+	err = metricWrapper.ShutdownMetrics(ctx)
+	require.NoError(t, err, "expected no error during ShutdownMetrics")
+
+	// After shutdown the handler reports that metrics are unavailable.
+	rec := httptest.NewRecorder()
+	metricWrapper.PrometheusHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestInitMetrics_NoReader(t *testing.T) {
+	// Reset global state so that nothing is initialized.
+	metricWrapper.ResetState()
+
+	cfg := metricWrapper.NewConfig(
+		"",
+		"test-service",
+		"test",
+		metricWrapper.WithExporter(metricWrapper.ExporterNone),
+	)
+	err := metricWrapper.InitMetrics(context.Background(), cfg)
+	require.ErrorContains(t, err, "no metric reader configured")
+
+	// Reset global state so that nothing is initialized.
+	metricWrapper.ResetState()
+
+	cfg = metricWrapper.NewConfig(
+		"localhost:4317",
+		"test-service",
+		"test",
+		metricWrapper.WithExporter("carrier-pigeon"),
+	)
+	err = metricWrapper.InitMetrics(context.Background(), cfg)
+	require.ErrorContains(t, err, "unsupported Exporter")
+}
@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	apimetric "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// Provider is a self-contained metrics pipeline: a MeterProvider together with
+// its exporters and readers. Unlike the package-level functions, which manage a
+// single default Provider, a process may create any number of Providers.
+type Provider struct {
+	mp      *sdkmetric.MeterProvider
+	promReg *prometheus.Registry
+	push    *swappableExporter
+
+	// mu guards cfg and serializes calls to Reconfigure.
+	mu  sync.Mutex
+	cfg Config
+
+	shutdownOnce sync.Once
+	shutdownErr  error
+}
+
+// NewProvider validates the config and builds a new metrics pipeline. The
+// Provider is not registered globally; call SetGlobal to do so.
+func NewProvider(ctx context.Context, cfg Config) (*Provider, error) {
+	if err := validateConfig(cfg); err != nil {
+		return nil, fmt.Errorf("invalid OTLP metrics config: %w", err)
+	}
+
+	// Create a resource to label the service. It is created before the
+	// readers, so that a failure does not leave exporters or files open.
+	r, err := resource.New(ctx,
+		resource.WithHost(),
+		resource.WithContainer(),
+		resource.WithAttributes(buildResourceAttributes(cfg)...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+
+	// Create the readers for the configured exporters.
+	rs, err := createReaders(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build custom histogram views if provided.
+	customViews := buildCustomViews(cfg.CustomHistogramViews)
+
+	// Build MeterProvider with optional custom views.
+	mpOpts := []sdkmetric.Option{
+		sdkmetric.WithResource(r),
+		sdkmetric.WithView(customViews...),
+	}
+	for _, reader := range rs.readers {
+		mpOpts = append(mpOpts, sdkmetric.WithReader(reader))
+	}
+
+	logPipeline(cfg)
+
+	return &Provider{
+		mp:      sdkmetric.NewMeterProvider(mpOpts...),
+		promReg: rs.promReg,
+		push:    rs.push,
+		cfg:     cfg,
+	}, nil
+}
+
+// logPipeline logs the exporters and readers of a pipeline, with header values redacted.
+func logPipeline(cfg Config) {
+	if exporterName(cfg) == ExporterOTLP {
+		log.Printf("[metrics] OTLP metrics initialized. Endpoint=%s Protocol=%s Insecure=%v Headers=%s Auth=%v",
+			cfg.OTLPEndpoint, otlpProtocol(cfg), cfg.OTLPInsecure, redactHeaders(cfg.OTLPHeaders), cfg.OTLPAuth != nil)
+	}
+	if exporterName(cfg) == ExporterStdout {
+		log.Printf("[metrics] Stdout metrics initialized. File=%q Pretty=%v", cfg.StdoutFile, cfg.StdoutPretty)
+	}
+	if cfg.Prometheus {
+		log.Printf("[metrics] Prometheus reader initialized. Serve PrometheusHandler() to expose metrics.")
+	}
+}
+
+// Meter returns a Meter with the given name from this Provider.
+func (p *Provider) Meter(name string, opts ...metric.MeterOption) metric.Meter {
+	return p.mp.Meter(name, opts...)
+}
+
+// MeterProvider returns the underlying SDK MeterProvider.
+func (p *Provider) MeterProvider() *sdkmetric.MeterProvider {
+	return p.mp
+}
+
+// SetGlobal registers this Provider as the OpenTelemetry global MeterProvider.
+func (p *Provider) SetGlobal() {
+	apimetric.SetMeterProvider(p.mp)
+}
+
+// PrometheusHandler returns an http.Handler serving this Provider's metrics in
+// the Prometheus text exposition format. It responds with 503 Service
+// Unavailable if the Prometheus reader is not enabled.
+func (p *Provider) PrometheusHandler() http.Handler {
+	return prometheusHandler(func() *prometheus.Registry { return p.promReg })
+}
+
+// Reconfigure applies cfg to the running Provider by replacing its push exporter,
+// e.g. to move to a new endpoint or rotate credentials. Instruments created from
+// the Provider keep working. Buffered metrics are flushed to the old exporter
+// before it is replaced and shut down. If cfg changes more than the push
+// exporter, ErrPipelineChanged is returned and the Provider is left unchanged.
+func (p *Provider) Reconfigure(ctx context.Context, cfg Config) error {
+	if err := validateConfig(cfg); err != nil {
+		return fmt.Errorf("invalid OTLP metrics config: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !samePipeline(p.cfg, cfg) {
+		return ErrPipelineChanged
+	}
+	if p.push == nil {
+		// Only the Prometheus reader is in use; there is nothing to replace.
+		p.cfg = cfg
+		return nil
+	}
+
+	// Create the new exporter first, so a failure leaves the pipeline untouched.
+	exporter, err := createPushExporter(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	// Flush buffered data to the old exporter before replacing it.
+	if err := p.mp.ForceFlush(ctx); err != nil {
+		log.Printf("[metrics] Flush before reconfigure failed: %v", err)
+	}
+	old := p.push.swap(exporter)
+	if err := old.Shutdown(ctx); err != nil {
+		log.Printf("[metrics] Shutdown of replaced exporter failed: %v", err)
+	}
+	p.cfg = cfg
+
+	logPipeline(cfg)
+	return nil
+}
+
+// ForceFlush exports all buffered metrics without shutting the Provider down.
+func (p *Provider) ForceFlush(ctx context.Context) error {
+	return p.mp.ForceFlush(ctx)
+}
+
+// Shutdown flushes and stops the Provider. Subsequent calls return the result
+// of the first one.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	p.shutdownOnce.Do(func() {
+		p.shutdownErr = p.mp.Shutdown(ctx)
+	})
+	return p.shutdownErr
+}
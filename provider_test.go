@@ -0,0 +1,116 @@
+package metrics_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	metricWrapper "github.com/janduursma/otel-metrics-wrapper-go"
+	"github.com/stretchr/testify/require"
+	apimetric "go.opentelemetry.io/otel"
+)
+
+// newStdoutProvider creates a Provider that writes its metrics to out.
+func newStdoutProvider(t *testing.T, out *syncBuffer, serviceName string) *metricWrapper.Provider {
+	t.Helper()
+
+	cfg := metricWrapper.NewConfig(
+		"",
+		serviceName,
+		"test",
+		metricWrapper.WithExporter(metricWrapper.ExporterStdout),
+		metricWrapper.WithStdoutWriter(out),
+		metricWrapper.WithPushInterval(time.Hour),
+	)
+	p, err := metricWrapper.NewProvider(context.Background(), cfg)
+	require.NoError(t, err, "expected no error creating Provider")
+	return p
+}
+
+func TestProvider_Independent(t *testing.T) {
+	ctx := context.Background()
+
+	// Two pipelines can run side by side in the same process.
+	var outA, outB syncBuffer
+	pA := newStdoutProvider(t, &outA, "service-a")
+	pB := newStdoutProvider(t, &outB, "service-b")
+
+	counterA, err := pA.Meter("test-meter").Int64Counter("a.calls")
+	require.NoError(t, err)
+	counterA.Add(ctx, 1)
+
+	counterB, err := pB.Meter("test-meter").Int64Counter("b.calls")
+	require.NoError(t, err)
+	counterB.Add(ctx, 1)
+
+	require.NoError(t, pA.ForceFlush(ctx))
+	require.NoError(t, pB.ForceFlush(ctx))
+
+	require.Contains(t, outA.String(), `"a.calls"`)
+	require.NotContains(t, outA.String(), `"b.calls"`)
+	require.Contains(t, outB.String(), `"b.calls"`)
+	require.NotContains(t, outB.String(), `"a.calls"`)
+
+	// Shutdown is idempotent.
+	require.NoError(t, pA.Shutdown(ctx))
+	require.NoError(t, pA.Shutdown(ctx))
+	require.NoError(t, pB.Shutdown(ctx))
+
+	// A new pipeline can be created after the previous one has shut down.
+	var outC syncBuffer
+	pC := newStdoutProvider(t, &outC, "service-a")
+	require.NoError(t, pC.Shutdown(ctx))
+}
+
+func TestProvider_SetGlobal(t *testing.T) {
+	// Restore the previous global MeterProvider afterwards.
+	prev := apimetric.GetMeterProvider()
+	defer apimetric.SetMeterProvider(prev)
+
+	var out syncBuffer
+	p := newStdoutProvider(t, &out, "test-service")
+	defer func() { _ = p.Shutdown(context.Background()) }()
+
+	p.SetGlobal()
+	require.Same(t, p.MeterProvider(), apimetric.GetMeterProvider())
+}
+
+func TestProvider_PrometheusHandler(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := metricWrapper.NewConfig(
+		"",
+		"test-service",
+		"test",
+		metricWrapper.WithExporter(metricWrapper.ExporterNone),
+		metricWrapper.WithPrometheus(true),
+	)
+	p, err := metricWrapper.NewProvider(ctx, cfg)
+	require.NoError(t, err)
+	defer func() { _ = p.Shutdown(ctx) }()
+
+	counter, err := p.Meter("test-meter").Int64Counter("jobs.processed")
+	require.NoError(t, err)
+	counter.Add(ctx, 3)
+
+	rec := httptest.NewRecorder()
+	p.PrometheusHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "jobs_processed_total")
+
+	// A Provider without the Prometheus reader has nothing to serve.
+	var out syncBuffer
+	other := newStdoutProvider(t, &out, "test-service")
+	defer func() { _ = other.Shutdown(ctx) }()
+
+	rec = httptest.NewRecorder()
+	other.PrometheusHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestNewProvider_InvalidConfig(t *testing.T) {
+	_, err := metricWrapper.NewProvider(context.Background(), metricWrapper.NewConfig("", "test-service", "test"))
+	require.ErrorContains(t, err, "OTLPEndpoint is required")
+}
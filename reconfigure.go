@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"reflect"
+	"sync"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// ErrPipelineChanged is returned by Provider.Reconfigure when the new Config
+// changes settings that are fixed for the lifetime of a MeterProvider, such as
+// the resource attributes, push interval, views or readers. Such changes
+// require a new Provider.
+var ErrPipelineChanged = errors.New("config changes require a new metrics pipeline")
+
+// swappableExporter delegates to an exporter that can be replaced at runtime.
+// Replacing it waits for an in-flight export to finish, so every export goes
+// entirely to either the old or the new exporter.
+type swappableExporter struct {
+	mu  sync.RWMutex
+	exp sdkmetric.Exporter
+}
+
+// Temporality implements sdkmetric.Exporter.
+func (s *swappableExporter) Temporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.exp.Temporality(kind)
+}
+
+// Aggregation implements sdkmetric.Exporter.
+func (s *swappableExporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.exp.Aggregation(kind)
+}
+
+// Export implements sdkmetric.Exporter.
+func (s *swappableExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.exp.Export(ctx, rm)
+}
+
+// ForceFlush implements sdkmetric.Exporter.
+func (s *swappableExporter) ForceFlush(ctx context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.exp.ForceFlush(ctx)
+}
+
+// Shutdown implements sdkmetric.Exporter.
+func (s *swappableExporter) Shutdown(ctx context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.exp.Shutdown(ctx)
+}
+
+// swap replaces the current exporter and returns the previous one.
+func (s *swappableExporter) swap(exp sdkmetric.Exporter) sdkmetric.Exporter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old := s.exp
+	s.exp = exp
+	return old
+}
+
+// samePipeline reports whether two configs differ only in settings of the
+// push exporter, which can be replaced without rebuilding the MeterProvider.
+func samePipeline(a, b Config) bool {
+	hasPush := func(cfg Config) bool { return exporterName(cfg) != ExporterNone }
+
+	return hasPush(a) == hasPush(b) &&
+		a.Prometheus == b.Prometheus &&
+		a.RuntimeHistograms == b.RuntimeHistograms &&
+		a.ServiceName == b.ServiceName &&
+		a.Environment == b.Environment &&
+		maps.Equal(a.ResourceAttributes, b.ResourceAttributes) &&
+		a.PushInterval == b.PushInterval &&
+		a.ExportTimeout == b.ExportTimeout &&
+		reflect.DeepEqual(a.CustomHistogramViews, b.CustomHistogramViews)
+}
@@ -0,0 +1,169 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	metricWrapper "github.com/janduursma/otel-metrics-wrapper-go"
+	"github.com/stretchr/testify/require"
+)
+
+// stdoutConfig returns a stdout exporter config writing to out.
+func stdoutConfig(out *syncBuffer, serviceName string) metricWrapper.Config {
+	return metricWrapper.NewConfig(
+		"",
+		serviceName,
+		"test",
+		metricWrapper.WithExporter(metricWrapper.ExporterStdout),
+		metricWrapper.WithStdoutWriter(out),
+		metricWrapper.WithPushInterval(time.Hour),
+	)
+}
+
+func TestReconfigure_SwapsExporterAndKeepsInstruments(t *testing.T) {
+	// Reset global state so that nothing is initialized.
+	metricWrapper.ResetState()
+
+	ctx := context.Background()
+	var oldOut, newOut syncBuffer
+
+	err := metricWrapper.InitMetrics(ctx, stdoutConfig(&oldOut, "test-service"))
+	require.NoError(t, err, "expected no error during InitMetrics")
+
+	counter, err := metricWrapper.GetMeter("test-meter").Int64Counter("jobs.processed")
+	require.NoError(t, err)
+	counter.Add(ctx, 1)
+
+	// Only the exporter changes, so it is swapped in place.
+	err = metricWrapper.Reconfigure(ctx, stdoutConfig(&newOut, "test-service"))
+	require.NoError(t, err, "expected no error during Reconfigure")
+
+	// The old pipeline was flushed before the swap.
+	require.Contains(t, oldOut.String(), `"jobs.processed"`)
+	require.Empty(t, newOut.String())
+
+	// The existing instrument now reports to the new exporter.
+	counter.Add(ctx, 1)
+	require.NoError(t, metricWrapper.ForceFlush(ctx))
+	require.Contains(t, newOut.String(), `"jobs.processed"`)
+
+	err = metricWrapper.ShutdownMetrics(ctx)
+	require.NoError(t, err, "expected no error during ShutdownMetrics")
+}
+
+func TestReconfigure_RebuildsPipeline(t *testing.T) {
+	// Reset global state so that nothing is initialized.
+	metricWrapper.ResetState()
+
+	ctx := context.Background()
+	var oldOut, newOut syncBuffer
+
+	err := metricWrapper.InitMetrics(ctx, stdoutConfig(&oldOut, "old-service"))
+	require.NoError(t, err, "expected no error during InitMetrics")
+
+	counter, err := metricWrapper.GetMeter("test-meter").Int64Counter("jobs.processed")
+	require.NoError(t, err)
+	counter.Add(ctx, 1)
+
+	// A new service name changes the resource, so the pipeline is rebuilt.
+	err = metricWrapper.Reconfigure(ctx, stdoutConfig(&newOut, "new-service"))
+	require.NoError(t, err, "expected no error during Reconfigure")
+	require.Contains(t, oldOut.String(), `"old-service"`)
+
+	// Instruments are recreated from the new default Provider.
+	counter, err = metricWrapper.GetMeter("test-meter").Int64Counter("jobs.processed")
+	require.NoError(t, err)
+	counter.Add(ctx, 1)
+	require.NoError(t, metricWrapper.ForceFlush(ctx))
+	require.Contains(t, newOut.String(), `"new-service"`)
+	require.Contains(t, newOut.String(), `"jobs.processed"`)
+
+	err = metricWrapper.ShutdownMetrics(ctx)
+	require.NoError(t, err, "expected no error during ShutdownMetrics")
+}
+
+func TestReconfigure_InvalidConfigKeepsPipeline(t *testing.T) {
+	// Reset global state so that nothing is initialized.
+	metricWrapper.ResetState()
+
+	ctx := context.Background()
+	var out syncBuffer
+
+	err := metricWrapper.InitMetrics(ctx, stdoutConfig(&out, "test-service"))
+	require.NoError(t, err, "expected no error during InitMetrics")
+
+	counter, err := metricWrapper.GetMeter("test-meter").Int64Counter("jobs.processed")
+	require.NoError(t, err)
+
+	// A config that fails validation is rejected without side effects.
+	err = metricWrapper.Reconfigure(ctx, metricWrapper.NewConfig("", "test-service", "test"))
+	require.Error(t, err)
+
+	counter.Add(ctx, 1)
+	require.NoError(t, metricWrapper.ForceFlush(ctx))
+	require.Contains(t, out.String(), `"jobs.processed"`)
+
+	err = metricWrapper.ShutdownMetrics(ctx)
+	require.NoError(t, err, "expected no error during ShutdownMetrics")
+}
+
+func TestReconfigure_NotInitialized(t *testing.T) {
+	// Reset global state so that nothing is initialized.
+	metricWrapper.ResetState()
+
+	ctx := context.Background()
+	var out syncBuffer
+
+	// Reconfigure initializes the pipeline if needed.
+	err := metricWrapper.Reconfigure(ctx, stdoutConfig(&out, "test-service"))
+	require.NoError(t, err, "expected no error during Reconfigure")
+
+	counter, err := metricWrapper.GetMeter("test-meter").Int64Counter("jobs.processed")
+	require.NoError(t, err)
+	counter.Add(ctx, 1)
+
+	err = metricWrapper.ShutdownMetrics(ctx)
+	require.NoError(t, err, "expected no error during ShutdownMetrics")
+	require.Contains(t, out.String(), `"jobs.processed"`)
+}
+
+func TestInitMetrics_AfterShutdown(t *testing.T) {
+	// Reset global state so that nothing is initialized.
+	metricWrapper.ResetState()
+
+	ctx := context.Background()
+	var first, second syncBuffer
+
+	err := metricWrapper.InitMetrics(ctx, stdoutConfig(&first, "test-service"))
+	require.NoError(t, err, "expected no error during InitMetrics")
+	err = metricWrapper.ShutdownMetrics(ctx)
+	require.NoError(t, err, "expected no error during ShutdownMetrics")
+
+	// A second InitMetrics sets up a fresh pipeline.
+	err = metricWrapper.InitMetrics(ctx, stdoutConfig(&second, "test-service"))
+	require.NoError(t, err, "expected no error during second InitMetrics")
+
+	counter, err := metricWrapper.GetMeter("test-meter").Int64Counter("jobs.processed")
+	require.NoError(t, err)
+	counter.Add(ctx, 1)
+
+	err = metricWrapper.ShutdownMetrics(ctx)
+	require.NoError(t, err, "expected no error during ShutdownMetrics")
+	require.Contains(t, second.String(), `"jobs.processed"`)
+}
+
+func TestProvider_ReconfigurePipelineChanged(t *testing.T) {
+	ctx := context.Background()
+	var out syncBuffer
+
+	p, err := metricWrapper.NewProvider(ctx, stdoutConfig(&out, "test-service"))
+	require.NoError(t, err)
+	defer func() { _ = p.Shutdown(ctx) }()
+
+	cfg := stdoutConfig(&out, "test-service")
+	cfg.PushInterval = time.Minute
+	err = p.Reconfigure(ctx, cfg)
+	require.True(t, errors.Is(err, metricWrapper.ErrPipelineChanged), "expected ErrPipelineChanged, got %v", err)
+}
@@ -3,16 +3,13 @@
 
 package metrics
 
-import "sync"
-
-// ResetState resets the package-level state (including initOnce, initialized flag,
-// meterProvider, and shutdownFunc) so that tests can reinitialize the metrics pipeline.
+// ResetState resets the package-level state (the initialized flag and
+// defaultProvider) so that tests can reinitialize the metrics pipeline.
 // This function is intended for testing only.
 func ResetState() {
-	// Reset the sync.Once so that InitMetrics will run again.
-	initOnce = sync.Once{}
+	// Forget the default Provider so that InitMetrics will run again.
+	mu.Lock()
+	defer mu.Unlock()
 	initialized = false
-	meterProvider = nil
-	shutdownOnce = sync.Once{}
-	shutdownFunc = nil
+	defaultProvider = nil
 }
@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Placeholders used by NormalizePath and ServeMuxRouteResolver.
+const (
+	idPlaceholder   = "{id}"
+	uuidPlaceholder = "{uuid}"
+	unmatchedRoute  = "unmatched"
+)
+
+// RouteResolver derives a low-cardinality route, such as "/users/{id}", from a
+// request. It returns an empty string if the route is unknown.
+type RouteResolver interface {
+	ResolveRoute(r *http.Request) string
+}
+
+// RouteResolverFunc adapts a function to the RouteResolver interface.
+type RouteResolverFunc func(r *http.Request) string
+
+// ResolveRoute implements RouteResolver.
+func (f RouteResolverFunc) ResolveRoute(r *http.Request) string {
+	return f(r)
+}
+
+// ServeMuxRouteResolver returns a RouteResolver that looks up the pattern mux
+// would match for the request, e.g. "GET /users/{id}", by routing it a second
+// time. Middleware needs no resolver for a ServeMux, as it reads the pattern the
+// mux has set on the request; use ServeMuxRouteResolver when the mux receives a
+// copy of the request, e.g. from a handler calling r.WithContext. Requests that
+// match no pattern are reported as "unmatched".
+func ServeMuxRouteResolver(mux *http.ServeMux) RouteResolver {
+	return RouteResolverFunc(func(r *http.Request) string {
+		return serveMuxRoute(mux, r)
+	})
+}
+
+// serveMuxRoute returns the pattern mux matches for r, or "unmatched".
+func serveMuxRoute(mux *http.ServeMux, r *http.Request) string {
+	if _, pattern := mux.Handler(r); pattern != "" {
+		return pattern
+	}
+	return unmatchedRoute
+}
+
+// MiddlewareOption configures Middleware.
+type MiddlewareOption func(*middlewareConfig)
+
+// middlewareConfig holds the settings of Middleware.
+type middlewareConfig struct {
+	resolver RouteResolver
+
+	// mux is the handler wrapped by Middleware, if it is an *http.ServeMux.
+	mux *http.ServeMux
+}
+
+// WithRouteResolver sets the RouteResolver used by Middleware to determine the
+// route attribute, e.g. for routers other than http.ServeMux.
+func WithRouteResolver(resolver RouteResolver) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		cfg.resolver = resolver
+	}
+}
+
+// resolveRoute determines the route of r once it has been served: from the
+// configured resolver, then from the ServeMux pattern that matched r, and
+// finally from the normalized path. If the wrapped handler is a ServeMux that
+// set no pattern, such as for a request it matched no pattern for, it is asked
+// for the pattern as a fallback.
+func (cfg middlewareConfig) resolveRoute(r *http.Request) string {
+	if cfg.resolver != nil {
+		if route := cfg.resolver.ResolveRoute(r); route != "" {
+			return route
+		}
+	}
+	if r.Pattern != "" {
+		return r.Pattern
+	}
+	if cfg.mux != nil {
+		return serveMuxRoute(cfg.mux, r)
+	}
+	return NormalizePath(r.URL.Path)
+}
+
+// NormalizePath replaces path segments that look like identifiers with
+// placeholders to keep the route attribute low-cardinality: numeric segments
+// become "{id}" and UUIDs become "{uuid}", so "/users/42/orders" is reported
+// as "/users/{id}/orders".
+func NormalizePath(path string) string {
+	if path == "" {
+		return "/"
+	}
+
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		switch {
+		case isNumeric(seg):
+			segments[i] = idPlaceholder
+		case isUUID(seg):
+			segments[i] = uuidPlaceholder
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// isNumeric reports whether s is a non-empty string of ASCII digits.
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isUUID reports whether s has the canonical 8-4-4-4-12 hexadecimal UUID form.
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			if !isHex(s[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isHex reports whether c is an ASCII hexadecimal digit.
+func isHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
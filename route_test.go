@@ -0,0 +1,103 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	metricWrapper "github.com/janduursma/otel-metrics-wrapper-go"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestNormalizePath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "", expected: "/"},
+		{path: "/", expected: "/"},
+		{path: "/users", expected: "/users"},
+		{path: "/users/42", expected: "/users/{id}"},
+		{path: "/users/42/orders/7", expected: "/users/{id}/orders/{id}"},
+		{path: "/orders/3f2504e0-4f89-11d3-9a0c-0305e82c3301", expected: "/orders/{uuid}"},
+		{path: "/orders/3F2504E0-4F89-11D3-9A0C-0305E82C3301/items", expected: "/orders/{uuid}/items"},
+		{path: "/v2/users/me", expected: "/v2/users/me"},
+		{path: "/files/3f2504e0-4f89-11d3-9a0c-0305e82c330", expected: "/files/3f2504e0-4f89-11d3-9a0c-0305e82c330"},
+		{path: "/users/42/", expected: "/users/{id}/"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			require.Equal(t, tc.expected, metricWrapper.NormalizePath(tc.path))
+		})
+	}
+}
+
+func TestMiddleware_ServeMuxRouteResolver(t *testing.T) {
+	hm, reader := newTestHTTPMetrics(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Wrap the whole mux without options; the pattern is resolved up front.
+	h := hm.Middleware(mux)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/43", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/no/such/page", nil))
+
+	rm := collect(t, reader)
+	require.EqualValues(t, 2, findIntSumByAttr(t, rm, "requests.total", attribute.String("route", "GET /users/{id}")))
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "requests.total", attribute.String("route", "unmatched")))
+}
+
+func TestMiddleware_ServeMuxRouteResolverWrapped(t *testing.T) {
+	hm, reader := newTestHTTPMetrics(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// The pattern the mux sets on the request is read once it has been served.
+	wrapped := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r)
+	})
+	hm.Middleware(wrapped).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+	// A mux that receives a copy of the request needs the resolver.
+	copied := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r.WithContext(r.Context()))
+	})
+	hm.Middleware(copied).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/43", nil))
+	h := hm.Middleware(copied, metricWrapper.WithRouteResolver(metricWrapper.ServeMuxRouteResolver(mux)))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/44", nil))
+
+	rm := collect(t, reader)
+	require.EqualValues(t, 2, findIntSumByAttr(t, rm, "requests.total", attribute.String("route", "GET /users/{id}")))
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "requests.total", attribute.String("route", "/users/{id}")))
+}
+
+func TestMiddleware_CustomRouteResolver(t *testing.T) {
+	hm, reader := newTestHTTPMetrics(t)
+
+	resolver := metricWrapper.RouteResolverFunc(func(r *http.Request) string {
+		if r.URL.Path == "/health" {
+			return "health"
+		}
+		return ""
+	})
+	h := hm.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}),
+		metricWrapper.WithRouteResolver(resolver),
+	)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	// An empty result falls back to the normalized path.
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/accounts/1234", nil))
+
+	rm := collect(t, reader)
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "requests.total", attribute.String("route", "health")))
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "requests.total", attribute.String("route", "/accounts/{id}")))
+}
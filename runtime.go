@@ -2,65 +2,125 @@ package metrics
 
 import (
 	"context"
-	"runtime"
+	"math"
+	"runtime/metrics"
+	"strings"
+	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 )
 
-// RuntimeMetrics holds the asynchronous gauges for
-// goroutines, memory usage, process uptime, etc.
+// Names of the runtime/metrics samples read by RuntimeMetrics.
+const (
+	rtGoroutines  = "/sched/goroutines:goroutines"
+	rtHeapAlloc   = "/memory/classes/heap/objects:bytes"
+	rtHeapObjects = "/gc/heap/objects:objects"
+	rtHeapGoal    = "/gc/heap/goal:bytes"
+	rtGCCycles    = "/gc/cycles/total:gc-cycles"
+	rtGCPauses    = "/sched/pauses/total/gc:seconds"
+	rtSchedLat    = "/sched/latencies:seconds"
+	rtGOMAXPROCS  = "/sched/gomaxprocs:threads"
+	rtCgoCalls    = "/cgo/go-to-c-calls:calls"
+	rtMutexWait   = "/sync/mutex/wait/total:seconds"
+
+	// rtMemoryClasses is the prefix of the memory class samples, which
+	// partition the memory mapped by the Go runtime.
+	rtMemoryClasses = "/memory/classes/"
+	rtMemoryTotal   = "/memory/classes/total:bytes"
+)
+
+// RuntimeMetrics holds the asynchronous instruments for goroutines, memory
+// usage, garbage collection, the scheduler, process uptime, etc.
 type RuntimeMetrics struct {
 	goroutines    metric.Int64ObservableGauge
 	memoryHeap    metric.Int64ObservableGauge
+	heapObjects   metric.Int64ObservableGauge
+	heapGoal      metric.Int64ObservableGauge
+	memoryClasses metric.Int64ObservableGauge
+	gcCycles      metric.Int64ObservableCounter
+	gcPauses      metric.Int64ObservableCounter
+	gomaxprocs    metric.Int64ObservableGauge
+	cgoCalls      metric.Int64ObservableCounter
+	mutexWait     metric.Float64ObservableCounter
 	processUptime metric.Int64ObservableGauge
 
 	startTime time.Time
+
+	// mu guards the samples, as the callback runs for each reader.
+	mu      sync.Mutex
+	samples []metrics.Sample
+	index   map[string]int
 }
 
-// NewRuntimeMetrics creates and registers asynchronous gauges that capture common
-// runtime metrics such as the number of goroutines, memory heap usage, and process
-// uptime. It returns a struct holding references to these instruments, and also
+// NewRuntimeMetrics creates and registers asynchronous instruments that capture
+// runtime metrics from the runtime/metrics package, which, unlike
+// runtime.ReadMemStats, does not stop the world:
+//
+//   - go.goroutines, go.sched.gomaxprocs: goroutines and GOMAXPROCS.
+//   - go.mem.heap_alloc, go.mem.heap_objects, go.mem.heap_goal: bytes and number
+//     of live and unswept heap objects, and the heap size target of the next GC.
+//   - go.mem.classes: the memory mapped by the runtime, by class attribute
+//     (e.g. "heap/free", "os-stacks").
+//   - go.gc.cycles, go.gc.pauses: the number of completed GC cycles and of
+//     stop-the-world pauses for the GC.
+//   - go.cgo.calls, go.sync.mutex.wait: calls from Go to C, and the total time
+//     goroutines spent blocked on a sync.Mutex or sync.RWMutex.
+//   - process.uptime: the time since NewRuntimeMetrics was called.
+//
+// It returns a struct holding references to these instruments, and also
 // registers a callback that the OpenTelemetry SDK periodically invokes to sample
-// their values.
+// their values. Metrics not supported by the running Go version are skipped.
+//
+// The histograms of GC pause times and scheduling latencies cannot be recorded
+// through asynchronous instruments; they are reported by NewRuntimeProducer.
 func NewRuntimeMetrics(meter metric.Meter) (*RuntimeMetrics, error) {
-	rm := &RuntimeMetrics{
-		startTime: time.Now(),
-	}
+	rm := &RuntimeMetrics{startTime: time.Now()}
 	var err error
 
-	rm.goroutines, err = meter.Int64ObservableGauge("go.goroutines")
-	if err != nil {
+	if rm.goroutines, err = meter.Int64ObservableGauge("go.goroutines"); err != nil {
 		return nil, err
 	}
-	rm.memoryHeap, err = meter.Int64ObservableGauge("go.mem.heap_alloc")
-	if err != nil {
+	if rm.memoryHeap, err = meter.Int64ObservableGauge("go.mem.heap_alloc", metric.WithUnit("By")); err != nil {
 		return nil, err
 	}
-	rm.processUptime, err = meter.Int64ObservableGauge("process.uptime")
-	if err != nil {
+	if rm.heapObjects, err = meter.Int64ObservableGauge("go.mem.heap_objects"); err != nil {
+		return nil, err
+	}
+	if rm.heapGoal, err = meter.Int64ObservableGauge("go.mem.heap_goal", metric.WithUnit("By")); err != nil {
+		return nil, err
+	}
+	if rm.memoryClasses, err = meter.Int64ObservableGauge("go.mem.classes", metric.WithUnit("By")); err != nil {
+		return nil, err
+	}
+	if rm.gcCycles, err = meter.Int64ObservableCounter("go.gc.cycles"); err != nil {
+		return nil, err
+	}
+	if rm.gcPauses, err = meter.Int64ObservableCounter("go.gc.pauses"); err != nil {
+		return nil, err
+	}
+	if rm.gomaxprocs, err = meter.Int64ObservableGauge("go.sched.gomaxprocs"); err != nil {
+		return nil, err
+	}
+	if rm.cgoCalls, err = meter.Int64ObservableCounter("go.cgo.calls"); err != nil {
+		return nil, err
+	}
+	if rm.mutexWait, err = meter.Float64ObservableCounter("go.sync.mutex.wait", metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if rm.processUptime, err = meter.Int64ObservableGauge("process.uptime", metric.WithUnit("s")); err != nil {
 		return nil, err
 	}
 
-	// Register a single callback for all three metrics.
+	rm.initSamples()
+
+	// Register a single callback for all metrics.
 	_, err = meter.RegisterCallback(
-		// This callback will be called once per collection interval.
-		func(_ context.Context, obs metric.Observer) error {
-			// Process goroutines.
-			obs.ObserveInt64(rm.goroutines, int64(runtime.NumGoroutine()))
-
-			// Process memory.
-			var mem runtime.MemStats
-			runtime.ReadMemStats(&mem)
-			obs.ObserveInt64(rm.memoryHeap, int64(mem.HeapAlloc))
-
-			// Process uptime.
-			uptimeSec := int64(time.Since(rm.startTime).Seconds())
-			obs.ObserveInt64(rm.processUptime, uptimeSec)
-
-			return nil
-		},
-		rm.goroutines, rm.memoryHeap, rm.processUptime,
+		rm.observe,
+		rm.goroutines, rm.memoryHeap, rm.heapObjects, rm.heapGoal, rm.memoryClasses,
+		rm.gcCycles, rm.gcPauses, rm.gomaxprocs,
+		rm.cgoCalls, rm.mutexWait, rm.processUptime,
 	)
 	if err != nil {
 		return nil, err
@@ -68,3 +128,107 @@ func NewRuntimeMetrics(meter metric.Meter) (*RuntimeMetrics, error) {
 
 	return rm, nil
 }
+
+// initSamples prepares the samples to read, including all memory classes
+// supported by the running Go version.
+func (rm *RuntimeMetrics) initSamples() {
+	names := []string{
+		rtGoroutines, rtHeapAlloc, rtHeapObjects, rtHeapGoal, rtGCCycles,
+		rtGCPauses, rtGOMAXPROCS, rtCgoCalls, rtMutexWait,
+	}
+	for _, desc := range metrics.All() {
+		if strings.HasPrefix(desc.Name, rtMemoryClasses) && desc.Name != rtMemoryTotal && desc.Name != rtHeapAlloc {
+			names = append(names, desc.Name)
+		}
+	}
+
+	rm.samples = make([]metrics.Sample, len(names))
+	rm.index = make(map[string]int, len(names))
+	for i, name := range names {
+		rm.samples[i].Name = name
+		rm.index[name] = i
+	}
+}
+
+// observe is called once per collection interval to sample all metrics.
+func (rm *RuntimeMetrics) observe(_ context.Context, obs metric.Observer) error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	metrics.Read(rm.samples)
+
+	rm.observeUint64(obs, rm.goroutines, rtGoroutines)
+	rm.observeUint64(obs, rm.memoryHeap, rtHeapAlloc)
+	rm.observeUint64(obs, rm.heapObjects, rtHeapObjects)
+	rm.observeUint64(obs, rm.heapGoal, rtHeapGoal)
+	rm.observeUint64(obs, rm.gcCycles, rtGCCycles)
+	rm.observeUint64(obs, rm.gomaxprocs, rtGOMAXPROCS)
+	rm.observeUint64(obs, rm.cgoCalls, rtCgoCalls)
+
+	if v, ok := rm.value(rtMutexWait, metrics.KindFloat64); ok {
+		obs.ObserveFloat64(rm.mutexWait, v.Float64())
+	}
+
+	// Memory classes, named by their path, e.g. "heap/free" or "os-stacks".
+	for _, s := range rm.samples {
+		if !strings.HasPrefix(s.Name, rtMemoryClasses) || s.Value.Kind() != metrics.KindUint64 {
+			continue
+		}
+		class, _, _ := strings.Cut(strings.TrimPrefix(s.Name, rtMemoryClasses), ":")
+		obs.ObserveInt64(rm.memoryClasses, clampInt64(s.Value.Uint64()),
+			metric.WithAttributes(attribute.String("class", class)))
+	}
+
+	// The number of GC pauses; their durations are reported by NewRuntimeProducer.
+	if h := rm.histogram(rtGCPauses); h != nil {
+		obs.ObserveInt64(rm.gcPauses, clampInt64(histogramCount(h)))
+	}
+
+	// Process uptime.
+	uptimeSec := int64(time.Since(rm.startTime).Seconds())
+	obs.ObserveInt64(rm.processUptime, uptimeSec)
+
+	return nil
+}
+
+// value returns the value of the named sample if it has the given kind; a
+// sample unsupported by the running Go version has kind metrics.KindBad.
+func (rm *RuntimeMetrics) value(name string, kind metrics.ValueKind) (metrics.Value, bool) {
+	i, ok := rm.index[name]
+	if !ok || rm.samples[i].Value.Kind() != kind {
+		return metrics.Value{}, false
+	}
+	return rm.samples[i].Value, true
+}
+
+// observeUint64 observes the named integer sample on inst, if it is supported.
+func (rm *RuntimeMetrics) observeUint64(obs metric.Observer, inst metric.Int64Observable, name string, opts ...metric.ObserveOption) {
+	if v, ok := rm.value(name, metrics.KindUint64); ok {
+		obs.ObserveInt64(inst, clampInt64(v.Uint64()), opts...)
+	}
+}
+
+// histogram returns the named histogram sample, or nil if it is unsupported.
+func (rm *RuntimeMetrics) histogram(name string) *metrics.Float64Histogram {
+	if v, ok := rm.value(name, metrics.KindFloat64Histogram); ok {
+		return v.Float64Histogram()
+	}
+	return nil
+}
+
+// histogramCount returns the total number of events in h.
+func histogramCount(h *metrics.Float64Histogram) uint64 {
+	var total uint64
+	for _, c := range h.Counts {
+		total += c
+	}
+	return total
+}
+
+// clampInt64 converts v to an int64, saturating at math.MaxInt64.
+func clampInt64(v uint64) int64 {
+	if v > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(v)
+}
@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"runtime/metrics"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// runtimeScope is the instrumentation scope of the metrics of NewRuntimeProducer.
+const runtimeScope = "github.com/janduursma/otel-metrics-wrapper-go"
+
+// runtimeBuckets are the explicit bucket boundaries, in seconds, of the
+// histograms reported by NewRuntimeProducer.
+var runtimeBuckets = []float64{1e-6, 1e-5, 1e-4, 5e-4, 1e-3, 5e-3, 1e-2, 5e-2, 1e-1, 1}
+
+// runtimeHistogram describes a runtime/metrics histogram reported by
+// NewRuntimeProducer.
+type runtimeHistogram struct {
+	sample      string
+	name        string
+	description string
+}
+
+// runtimeHistograms are the histograms reported by NewRuntimeProducer.
+var runtimeHistograms = []runtimeHistogram{
+	{sample: rtGCPauses, name: "go.gc.pause.duration", description: "Stop-the-world pause times of the garbage collector."},
+	{sample: rtSchedLat, name: "go.sched.latency", description: "Time goroutines spent runnable before running."},
+}
+
+// runtimeProducer is the sdkmetric.Producer returned by NewRuntimeProducer.
+type runtimeProducer struct {
+	start time.Time
+
+	// mu guards the samples, as Produce runs for each collection.
+	mu      sync.Mutex
+	samples []metrics.Sample
+}
+
+// NewRuntimeProducer returns an sdkmetric.Producer reporting the histograms that
+// the Go runtime maintains, which cannot be recorded through asynchronous
+// instruments:
+//
+//   - go.gc.pause.duration: the stop-the-world pause times of the GC.
+//   - go.sched.latency: the time goroutines spent runnable before running.
+//
+// Both are cumulative histograms in seconds, with the boundaries 1e-06 up to 1.
+// The runtime does not track the sum of the durations, so it is estimated from
+// the midpoints of the runtime's own, much finer, buckets. Histograms not
+// supported by the running Go version are skipped.
+//
+// The producer is registered on the readers of a Provider with
+// WithRuntimeHistograms, or on a reader of your own with
+// sdkmetric.WithProducer.
+func NewRuntimeProducer() sdkmetric.Producer {
+	p := &runtimeProducer{
+		start:   time.Now(),
+		samples: make([]metrics.Sample, len(runtimeHistograms)),
+	}
+	for i, h := range runtimeHistograms {
+		p.samples[i].Name = h.sample
+	}
+	return p
+}
+
+// Produce implements sdkmetric.Producer.
+func (p *runtimeProducer) Produce(context.Context) ([]metricdata.ScopeMetrics, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	metrics.Read(p.samples)
+	now := time.Now()
+
+	sm := metricdata.ScopeMetrics{Scope: instrumentation.Scope{Name: runtimeScope}}
+	for i, s := range p.samples {
+		if s.Value.Kind() != metrics.KindFloat64Histogram {
+			continue
+		}
+		sm.Metrics = append(sm.Metrics, metricdata.Metrics{
+			Name:        runtimeHistograms[i].name,
+			Description: runtimeHistograms[i].description,
+			Unit:        "s",
+			Data: metricdata.Histogram[float64]{
+				Temporality: metricdata.CumulativeTemporality,
+				DataPoints: []metricdata.HistogramDataPoint[float64]{
+					histogramDataPoint(s.Value.Float64Histogram(), p.start, now),
+				},
+			},
+		})
+	}
+	return []metricdata.ScopeMetrics{sm}, nil
+}
+
+// histogramDataPoint converts h to a data point with the runtimeBuckets
+// boundaries. Each bucket of h is counted in the first bucket whose upper bound
+// is at or above its own, which is accurate to the resolution of the runtime's
+// buckets.
+func histogramDataPoint(h *metrics.Float64Histogram, start, now time.Time) metricdata.HistogramDataPoint[float64] {
+	dp := metricdata.HistogramDataPoint[float64]{
+		StartTime:    start,
+		Time:         now,
+		Bounds:       runtimeBuckets,
+		BucketCounts: make([]uint64, len(runtimeBuckets)+1),
+	}
+	for i, c := range h.Counts {
+		if c == 0 {
+			continue
+		}
+		lower, upper := h.Buckets[i], h.Buckets[i+1]
+		j := 0
+		for j < len(runtimeBuckets) && runtimeBuckets[j] < upper {
+			j++
+		}
+		dp.BucketCounts[j] += c
+		dp.Count += c
+		dp.Sum += float64(c) * bucketMidpoint(lower, upper)
+	}
+	return dp
+}
+
+// bucketMidpoint returns a representative value of the bucket [lower, upper),
+// either bound of which may be infinite.
+func bucketMidpoint(lower, upper float64) float64 {
+	switch {
+	case math.IsInf(lower, -1):
+		return max(upper, 0)
+	case math.IsInf(upper, 1):
+		return lower
+	default:
+		return (lower + upper) / 2
+	}
+}
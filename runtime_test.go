@@ -2,12 +2,16 @@ package metrics_test
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
 	"testing"
 	"time"
 
 	metricWrapper "github.com/janduursma/otel-metrics-wrapper-go"
 
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 )
@@ -15,7 +19,7 @@ import (
 // TestRuntimeMetrics verifies that the asynchronous gauges for goroutines,
 // memory heap allocation, and process uptime are being recorded by the callback.
 func TestRuntimeMetrics(t *testing.T) {
-	// Reset global state so that 'initialized' is false and 'meterProvider' is nil.
+	// Reset global state so that 'initialized' is false and 'defaultProvider' is nil.
 	metricWrapper.ResetState()
 
 	ctx := context.Background()
@@ -35,6 +39,9 @@ func TestRuntimeMetrics(t *testing.T) {
 	_, err := metricWrapper.NewRuntimeMetrics(meter)
 	require.NoError(t, err, "failed to create RuntimeMetrics.")
 
+	// Trigger a GC cycle, so that pause times are reported.
+	runtime.GC()
+
 	// Allow some time for the callback to run and capture uptime.
 	time.Sleep(1 * time.Second)
 
@@ -53,7 +60,97 @@ func TestRuntimeMetrics(t *testing.T) {
 	heapAlloc := findGaugeValueByName(t, res, "go.mem.heap_alloc")
 	require.Greater(t, heapAlloc, int64(0), "expected heap allocation > 0, got %d", heapAlloc)
 
+	// Expect the heap goal, live objects and GOMAXPROCS to be reported.
+	require.Greater(t, findGaugeValueByName(t, res, "go.mem.heap_goal"), int64(0))
+	require.Greater(t, findGaugeValueByName(t, res, "go.mem.heap_objects"), int64(0))
+	require.EqualValues(t, runtime.GOMAXPROCS(0), findGaugeValueByName(t, res, "go.sched.gomaxprocs"))
+
+	// Expect the heap objects memory class to match the heap allocation.
+	require.Greater(t, findGaugeValueByAttr(t, res, "go.mem.classes", attribute.String("class", "heap/objects")), int64(0))
+
+	// Expect at least the forced GC cycle and its pauses.
+	require.GreaterOrEqual(t, findIntSumByName(t, res, "go.gc.cycles"), int64(1))
+	require.GreaterOrEqual(t, findIntSumByName(t, res, "go.gc.pauses"), int64(1))
+
 	// Assert that uptime is greater than 0.
 	uptime := findGaugeValueByName(t, res, "process.uptime")
 	require.Greater(t, uptime, int64(0), "expected uptime > 0, got %d", uptime)
 }
+
+// findRuntimeHistogram returns the data point of the named histogram.
+func findRuntimeHistogram(t *testing.T, rm metricdata.ResourceMetrics, name string) metricdata.HistogramDataPoint[float64] {
+	t.Helper()
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			require.Equal(t, "s", m.Unit)
+			h, ok := m.Data.(metricdata.Histogram[float64])
+			require.True(t, ok, "metric %q is not a float64 histogram", name)
+			require.Equal(t, metricdata.CumulativeTemporality, h.Temporality)
+			require.Len(t, h.DataPoints, 1)
+			return h.DataPoints[0]
+		}
+	}
+	t.Fatalf("metric %q not found", name)
+	return metricdata.HistogramDataPoint[float64]{}
+}
+
+func TestRuntimeProducer(t *testing.T) {
+	// The producer is stateless between collections, so readers sharing it each
+	// see all pauses, whichever collected first.
+	producer := metricWrapper.NewRuntimeProducer()
+	first := metric.NewManualReader(metric.WithProducer(producer))
+	second := metric.NewManualReader(metric.WithProducer(producer))
+	mp := metric.NewMeterProvider(metric.WithReader(first), metric.WithReader(second))
+	_, err := metricWrapper.NewRuntimeMetrics(mp.Meter("test-meter"))
+	require.NoError(t, err)
+
+	runtime.GC()
+	runtime.GC()
+
+	firstRes := collect(t, first)
+	pauses := findRuntimeHistogram(t, firstRes, "go.gc.pause.duration")
+	require.GreaterOrEqual(t, pauses.Count, uint64(2))
+	require.GreaterOrEqual(t, pauses.Count, uint64(findIntSumByName(t, firstRes, "go.gc.pauses")))
+	require.Len(t, pauses.BucketCounts, len(pauses.Bounds)+1)
+	var total uint64
+	for _, c := range pauses.BucketCounts {
+		total += c
+	}
+	require.Equal(t, pauses.Count, total, "the buckets count all pauses")
+	require.Greater(t, pauses.Sum, 0.0)
+
+	latency := findRuntimeHistogram(t, firstRes, "go.sched.latency")
+	require.Greater(t, latency.Count, uint64(0))
+
+	secondPauses := findRuntimeHistogram(t, collect(t, second), "go.gc.pause.duration")
+	require.GreaterOrEqual(t, secondPauses.Count, pauses.Count)
+}
+
+func TestProvider_RuntimeHistogramsPrometheus(t *testing.T) {
+	ctx := context.Background()
+	cfg := metricWrapper.NewConfig("", "test-service", "test",
+		metricWrapper.WithExporter(metricWrapper.ExporterNone),
+		metricWrapper.WithPrometheus(true),
+		metricWrapper.WithRuntimeHistograms(true),
+	)
+	p, err := metricWrapper.NewProvider(ctx, cfg)
+	require.NoError(t, err)
+	defer func() { _ = p.Shutdown(ctx) }()
+
+	runtime.GC()
+
+	rec := httptest.NewRecorder()
+	p.PrometheusHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// The histograms are exposed with buckets, sum and count.
+	out := rec.Body.String()
+	require.Contains(t, out, "# TYPE go_gc_pause_duration_seconds histogram")
+	require.Contains(t, out, `go_gc_pause_duration_seconds_bucket{`)
+	require.Contains(t, out, "go_gc_pause_duration_seconds_sum{")
+	require.Contains(t, out, "go_sched_latency_seconds_count{")
+}
@@ -0,0 +1,475 @@
+package metrics
+
+import (
+	"strings"
+)
+
+// sqlTokenKind is the kind of a token produced by tokenizeSQL.
+type sqlTokenKind int
+
+const (
+	// tokWord is an unquoted keyword or identifier.
+	tokWord sqlTokenKind = iota
+	// tokIdent is a quoted identifier; its text is unquoted.
+	tokIdent
+	// tokPunct is a single punctuation or operator character.
+	tokPunct
+	// tokLiteral is a string or numeric literal, or a bind parameter.
+	tokLiteral
+)
+
+// sqlDialect selects the lexical rules applied by tokenizeSQL.
+type sqlDialect int
+
+const (
+	// dialectStandard follows standard SQL, with SQLite's bracket-quoted identifiers.
+	dialectStandard sqlDialect = iota
+	// dialectPostgres follows Postgres, where brackets are array subscripts.
+	dialectPostgres
+	// dialectMySQL follows MySQL and MariaDB in their default SQL mode.
+	dialectMySQL
+)
+
+// sqlToken is a token of a SQL statement. The depth is the number of
+// parentheses enclosing it; a parenthesis has the depth of its surroundings.
+type sqlToken struct {
+	kind  sqlTokenKind
+	text  string
+	depth int
+}
+
+// isKeyword reports whether t is the unquoted keyword kw, in any case.
+func (t sqlToken) isKeyword(kw string) bool {
+	return t.kind == tokWord && strings.EqualFold(t.text, kw)
+}
+
+// isPunct reports whether t is the punctuation character p.
+func (t sqlToken) isPunct(p string) bool {
+	return t.kind == tokPunct && t.text == p
+}
+
+// isName reports whether t can be (part of) a table name.
+func (t sqlToken) isName() bool {
+	return t.kind == tokWord || t.kind == tokIdent
+}
+
+// ParseSQL extracts the operation and primary table of a SQL statement, for use
+// as the operation and table attributes of DBMetrics. Comments and string
+// literals are ignored, and quoted identifiers of the Postgres, MySQL and SQLite
+// dialects are unquoted, so schema-qualified names are returned as
+// "schema.table".
+//
+// The operation is the leading verb in upper case, with the statement following
+// the common table expressions of a WITH clause used as the verb. Inserts that
+// update on conflict (ON CONFLICT DO UPDATE, ON DUPLICATE KEY UPDATE, INSERT OR
+// REPLACE, REPLACE and UPSERT) are reported as "UPSERT". The table is the target
+// of INSERT, UPDATE, DELETE, MERGE and DDL statements on tables, and the first
+// table in the FROM clause of a SELECT. Either value is empty if it cannot be
+// determined.
+//
+// ParseSQL follows the lexical rules of standard SQL, as SQLite does:
+// backslashes in string literals are only escapes in Postgres E'...' strings,
+// '#' does not start a comment, and [name] is a quoted identifier. Use
+// ParsePostgres for Postgres and ParseMySQL for MySQL and MariaDB.
+func ParseSQL(query string) (operation, table string) {
+	p := sqlParser{toks: tokenizeSQL(query, dialectStandard)}
+	return p.parse()
+}
+
+// ParsePostgres is like ParseSQL, but follows the lexical rules of Postgres,
+// where brackets are array subscripts rather than quoted identifiers.
+func ParsePostgres(query string) (operation, table string) {
+	p := sqlParser{toks: tokenizeSQL(query, dialectPostgres)}
+	return p.parse()
+}
+
+// ParseMySQL is like ParseSQL, but follows the lexical rules of MySQL and
+// MariaDB in their default SQL mode: '#' starts a line comment, '"' delimits a
+// string rather than an identifier, and backslashes escape characters in
+// string literals.
+func ParseMySQL(query string) (operation, table string) {
+	p := sqlParser{toks: tokenizeSQL(query, dialectMySQL)}
+	return p.parse()
+}
+
+// parserFor returns the SQL parser matching the lexical rules of dbSystem.
+func parserFor(dbSystem string) func(query string) (operation, table string) {
+	switch strings.ToLower(dbSystem) {
+	case "mysql", "mariadb":
+		return ParseMySQL
+	case "postgres", "postgresql", "cockroachdb":
+		return ParsePostgres
+	default:
+		return ParseSQL
+	}
+}
+
+// sqlParser derives the operation and table from the tokens of a statement.
+type sqlParser struct {
+	toks []sqlToken
+}
+
+// parse returns the operation and table of the statement.
+func (p *sqlParser) parse() (operation, table string) {
+	i := 0
+	for i < len(p.toks) && p.toks[i].isPunct("(") {
+		i++
+	}
+	if i >= len(p.toks) || p.toks[i].kind != tokWord {
+		return "", ""
+	}
+
+	var ctes map[string]bool
+	if p.toks[i].isKeyword("WITH") {
+		i, ctes = p.skipCTEs(i + 1)
+		if i >= len(p.toks) || p.toks[i].kind != tokWord {
+			return "WITH", ""
+		}
+	}
+
+	verb := strings.ToUpper(p.toks[i].text)
+	depth := p.toks[i].depth
+	operation = verb
+
+	switch verb {
+	case "SELECT":
+		table = p.fromTable(i+1, depth)
+	case "INSERT":
+		if p.isUpsert(i+1, depth) {
+			operation = "UPSERT"
+		}
+		table = p.nameAfter(i+1, depth, "INTO")
+	case "REPLACE", "UPSERT":
+		operation = "UPSERT"
+		table = p.nameAfter(i+1, depth, "INTO")
+	case "UPDATE":
+		table = p.readName(p.skipModifiers(i+1), true)
+	case "DELETE":
+		table = p.nameAfter(i+1, depth, "FROM")
+	case "MERGE":
+		table = p.nameAfter(i+1, depth, "INTO")
+	case "CREATE", "ALTER", "DROP":
+		table = p.nameAfter(i+1, depth, "TABLE")
+	case "TRUNCATE":
+		j := i + 1
+		if j < len(p.toks) && p.toks[j].isKeyword("TABLE") {
+			j++
+		}
+		table = p.readName(p.skipModifiers(j), true)
+	}
+
+	// A common table expression is not a table.
+	if ctes[strings.ToLower(table)] {
+		table = ""
+	}
+	return operation, table
+}
+
+// skipCTEs skips the common table expressions following WITH at index i, and
+// returns the index of the main statement together with the names defined.
+func (p *sqlParser) skipCTEs(i int) (int, map[string]bool) {
+	ctes := make(map[string]bool)
+	if i < len(p.toks) && p.toks[i].isKeyword("RECURSIVE") {
+		i++
+	}
+	for i < len(p.toks) && p.toks[i].isName() {
+		ctes[strings.ToLower(p.toks[i].text)] = true
+		i++
+		// Optional column list.
+		if i < len(p.toks) && p.toks[i].isPunct("(") {
+			i = p.skipParens(i)
+		}
+		if i < len(p.toks) && p.toks[i].isKeyword("AS") {
+			i++
+		}
+		for i < len(p.toks) && (p.toks[i].isKeyword("NOT") || p.toks[i].isKeyword("MATERIALIZED")) {
+			i++
+		}
+		if i < len(p.toks) && p.toks[i].isPunct("(") {
+			i = p.skipParens(i)
+		}
+		if i >= len(p.toks) || !p.toks[i].isPunct(",") {
+			break
+		}
+		i++
+	}
+	return i, ctes
+}
+
+// skipParens returns the index after the parenthesis matching the one at i.
+func (p *sqlParser) skipParens(i int) int {
+	depth := p.toks[i].depth
+	for j := i + 1; j < len(p.toks); j++ {
+		if p.toks[j].isPunct(")") && p.toks[j].depth == depth {
+			return j + 1
+		}
+	}
+	return len(p.toks)
+}
+
+// skipModifiers skips the keywords that may precede a table name, such as ONLY,
+// IF [NOT] EXISTS, and the MySQL and SQLite conflict clauses.
+func (p *sqlParser) skipModifiers(i int) int {
+	for i < len(p.toks) {
+		t := p.toks[i]
+		switch {
+		case t.isKeyword("ONLY"), t.isKeyword("IF"), t.isKeyword("NOT"), t.isKeyword("EXISTS"),
+			t.isKeyword("LOW_PRIORITY"), t.isKeyword("IGNORE"), t.isKeyword("QUICK"),
+			t.isKeyword("TEMPORARY"), t.isKeyword("TEMP"), t.isKeyword("UNLOGGED"):
+			i++
+		case t.isKeyword("OR"):
+			// SQLite: UPDATE OR REPLACE, UPDATE OR IGNORE, etc.
+			i += 2
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// nameAfter returns the name following the first occurrence of keyword at the
+// given depth, from index i on.
+func (p *sqlParser) nameAfter(i, depth int, keyword string) string {
+	for j := i; j < len(p.toks); j++ {
+		if p.toks[j].depth == depth && p.toks[j].isKeyword(keyword) {
+			return p.readName(p.skipModifiers(j+1), true)
+		}
+	}
+	return ""
+}
+
+// fromTable returns the first table of the FROM clause of a SELECT starting at
+// i. If that is a subquery, the first table of the innermost FROM clause found
+// is used instead.
+func (p *sqlParser) fromTable(i, depth int) string {
+	for _, sameDepth := range []bool{true, false} {
+		for j := i; j < len(p.toks); j++ {
+			t := p.toks[j]
+			if sameDepth && t.depth != depth || !t.isKeyword("FROM") {
+				continue
+			}
+			// IS [NOT] DISTINCT FROM is a comparison.
+			if j > 0 && p.toks[j-1].isKeyword("DISTINCT") {
+				continue
+			}
+			if name := p.readName(p.skipModifiers(j+1), false); name != "" {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// readName reads a possibly schema-qualified name at i. Unless allowCall is set,
+// a name followed by an opening parenthesis is a function call rather than a
+// table, and an empty string is returned.
+func (p *sqlParser) readName(i int, allowCall bool) string {
+	if i >= len(p.toks) || !p.toks[i].isName() {
+		return ""
+	}
+	parts := []string{p.toks[i].text}
+	i++
+	for i+1 < len(p.toks) && p.toks[i].isPunct(".") && p.toks[i+1].isName() {
+		parts = append(parts, p.toks[i+1].text)
+		i += 2
+	}
+	if !allowCall && i < len(p.toks) && p.toks[i].isPunct("(") {
+		return ""
+	}
+	return strings.Join(parts, ".")
+}
+
+// isUpsert reports whether the INSERT statement starting at i updates on
+// conflict.
+func (p *sqlParser) isUpsert(i, depth int) bool {
+	// SQLite: INSERT OR REPLACE.
+	if i+1 < len(p.toks) && p.toks[i].isKeyword("OR") && p.toks[i+1].isKeyword("REPLACE") {
+		return true
+	}
+	for j := i; j+1 < len(p.toks); j++ {
+		t := p.toks[j]
+		if t.depth != depth {
+			continue
+		}
+		switch {
+		case t.isKeyword("DUPLICATE") && p.toks[j+1].isKeyword("KEY"):
+			// MySQL: ON DUPLICATE KEY UPDATE.
+			return true
+		case t.isKeyword("DO") && p.toks[j+1].isKeyword("UPDATE"):
+			// Postgres and SQLite: ON CONFLICT ... DO UPDATE.
+			return true
+		}
+	}
+	return false
+}
+
+// tokenizeSQL splits query into tokens, dropping whitespace and comments, with
+// the lexical rules of dialect.
+func tokenizeSQL(query string, dialect sqlDialect) []sqlToken {
+	mysql := dialect == dialectMySQL
+	var (
+		toks  []sqlToken
+		depth int
+	)
+	add := func(kind sqlTokenKind, text string) {
+		toks = append(toks, sqlToken{kind: kind, text: text, depth: depth})
+	}
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+		case c == '-' && strings.HasPrefix(query[i:], "--"), c == '#' && mysql:
+			// Line comment; '#' only starts one in MySQL.
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				return toks
+			}
+			i += end + 1
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			i = skipBlockComment(query, i)
+		case c == '\'':
+			end := skipQuoted(query, i, '\'', mysql)
+			add(tokLiteral, query[i:end])
+			i = end
+		case c == '"' && mysql:
+			// A string in MySQL's default SQL mode.
+			end := skipQuoted(query, i, c, true)
+			add(tokLiteral, query[i:end])
+			i = end
+		case c == '"' || c == '`':
+			end := skipQuoted(query, i, c, false)
+			add(tokIdent, unquoteIdent(query[i:end], c))
+			i = end
+		case c == '[' && dialect == dialectStandard:
+			// SQLite bracket-quoted identifier; an unterminated one runs to the
+			// end of the query.
+			end := strings.IndexByte(query[i+1:], ']')
+			if end < 0 {
+				add(tokIdent, query[i+1:])
+				return toks
+			}
+			add(tokIdent, query[i+1:i+1+end])
+			i += end + 2
+		case c == '$':
+			end := skipDollar(query, i)
+			add(tokLiteral, query[i:end])
+			i = end
+		case (c == 'E' || c == 'e') && strings.HasPrefix(query[i+1:], "'"):
+			// Postgres string with C-style escapes: E'it\'s'.
+			end := skipQuoted(query, i+1, '\'', true)
+			add(tokLiteral, query[i:end])
+			i = end
+		case isIdentStart(c):
+			end := i + 1
+			for end < len(query) && isIdentPart(query[end]) {
+				end++
+			}
+			add(tokWord, query[i:end])
+			i = end
+		case '0' <= c && c <= '9':
+			end := i + 1
+			for end < len(query) && (isIdentPart(query[end]) || query[end] == '.') {
+				end++
+			}
+			add(tokLiteral, query[i:end])
+			i = end
+		case c == '(':
+			add(tokPunct, "(")
+			depth++
+			i++
+		case c == ')':
+			depth = max(depth-1, 0)
+			add(tokPunct, ")")
+			i++
+		default:
+			add(tokPunct, query[i:i+1])
+			i++
+		}
+	}
+	return toks
+}
+
+// skipBlockComment returns the index after the block comment starting at i.
+// Comments nest, as in Postgres.
+func skipBlockComment(query string, i int) int {
+	nesting := 0
+	for i < len(query) {
+		switch {
+		case strings.HasPrefix(query[i:], "/*"):
+			nesting++
+			i += 2
+		case strings.HasPrefix(query[i:], "*/"):
+			nesting--
+			i += 2
+			if nesting == 0 {
+				return i
+			}
+		default:
+			i++
+		}
+	}
+	return len(query)
+}
+
+// skipQuoted returns the index after the quoted string starting at i. A doubled
+// quote is an escaped quote, as is a backslash-escaped one if backslash is set.
+func skipQuoted(query string, i int, quote byte, backslash bool) int {
+	for j := i + 1; j < len(query); j++ {
+		switch query[j] {
+		case '\\':
+			if backslash {
+				j++
+			}
+		case quote:
+			if j+1 < len(query) && query[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(query)
+}
+
+// unquoteIdent removes the quotes around a quoted identifier and unescapes
+// doubled quotes.
+func unquoteIdent(s string, quote byte) string {
+	s = strings.TrimPrefix(s, string(quote))
+	s = strings.TrimSuffix(s, string(quote))
+	return strings.ReplaceAll(s, string(quote)+string(quote), string(quote))
+}
+
+// skipDollar returns the index after a Postgres bind parameter ($1) or
+// dollar-quoted string ($$...$$ or $tag$...$tag$) starting at i.
+func skipDollar(query string, i int) int {
+	j := i + 1
+	if j < len(query) && '0' <= query[j] && query[j] <= '9' {
+		for j < len(query) && '0' <= query[j] && query[j] <= '9' {
+			j++
+		}
+		return j
+	}
+	for j < len(query) && isIdentPart(query[j]) && query[j] != '$' {
+		j++
+	}
+	if j >= len(query) || query[j] != '$' {
+		return i + 1
+	}
+	tag := query[i : j+1]
+	if end := strings.Index(query[j+1:], tag); end >= 0 {
+		return j + 1 + end + len(tag)
+	}
+	return len(query)
+}
+
+// isIdentStart reports whether c can start an unquoted identifier.
+func isIdentStart(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || c >= 0x80
+}
+
+// isIdentPart reports whether c can continue an unquoted identifier.
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || '0' <= c && c <= '9' || c == '$'
+}
@@ -0,0 +1,119 @@
+package metrics_test
+
+import (
+	"testing"
+
+	metricWrapper "github.com/janduursma/otel-metrics-wrapper-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSQL(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		operation string
+		table     string
+	}{
+		{"empty", "", "", ""},
+		{"only comment", "-- nothing", "", ""},
+		{"select", "SELECT id, name FROM users WHERE id = $1", "SELECT", "users"},
+		{"lower case", "select * from orders o join users u on u.id = o.user_id", "SELECT", "orders"},
+		{"schema qualified", `SELECT * FROM "public"."Users"`, "SELECT", "public.Users"},
+		{"mysql backticks", "SELECT * FROM `shop`.`orders` LIMIT 1", "SELECT", "shop.orders"},
+		{"sqlite brackets", "SELECT * FROM [order items]", "SELECT", "order items"},
+		{"no table", "SELECT 1", "SELECT", ""},
+		{"function call", "SELECT EXTRACT(YEAR FROM now())", "SELECT", ""},
+		{"subquery", "SELECT count(*) FROM (SELECT id FROM events WHERE kind = 'x') e", "SELECT", "events"},
+		{"scalar subquery", "SELECT (SELECT max(id) FROM a) FROM b", "SELECT", "b"},
+		{"distinct from", "SELECT a IS DISTINCT FROM b FROM t", "SELECT", "t"},
+		{"leading comments", "/* app:api */ -- trace\n  SELECT * FROM users", "SELECT", "users"},
+		{"nested comment", "/* outer /* inner */ FROM x */ SELECT * FROM users", "SELECT", "users"},
+		{"hash is an operator", "SELECT a # b FROM t", "SELECT", "t"},
+		{"json operator", "SELECT data #>> '{a}' FROM docs", "SELECT", "docs"},
+		{"string literal", "SELECT 'FROM fake' AS s, 'it''s' FROM real", "SELECT", "real"},
+		{"backslash is literal", `SELECT 'C:\' FROM t`, "SELECT", "t"},
+		{"escape string", `SELECT E'it\'s FROM fake' FROM real`, "SELECT", "real"},
+		{"unterminated bracket", "SELECT a[", "SELECT", ""},
+		{"only bracket", "[", "", ""},
+		{"unterminated bracket table", "SELECT * FROM [users", "SELECT", "users"},
+		{"dollar quoted", "SELECT $tag$ FROM fake $tag$ FROM real", "SELECT", "real"},
+		{"parenthesized", "(SELECT * FROM a) UNION (SELECT * FROM b)", "SELECT", "a"},
+		{"insert", "INSERT INTO users (name) VALUES ($1)", "INSERT", "users"},
+		{"insert select", "INSERT INTO archive SELECT * FROM events", "INSERT", "archive"},
+		{"insert ignore", "INSERT IGNORE INTO users (name) VALUES (?)", "INSERT", "users"},
+		{"insert do nothing", "INSERT INTO users (id) VALUES (1) ON CONFLICT DO NOTHING", "INSERT", "users"},
+		{"postgres upsert", "INSERT INTO users (id) VALUES (1) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name", "UPSERT", "users"},
+		{"mysql upsert", "INSERT INTO users (id) VALUES (1) ON DUPLICATE KEY UPDATE name = VALUES(name)", "UPSERT", "users"},
+		{"sqlite upsert", "INSERT OR REPLACE INTO users (id) VALUES (1)", "UPSERT", "users"},
+		{"replace", "REPLACE INTO users (id) VALUES (1)", "UPSERT", "users"},
+		{"upsert", "UPSERT INTO users (id) VALUES (1)", "UPSERT", "users"},
+		{"update", "UPDATE users SET name = $1 WHERE id = $2", "UPDATE", "users"},
+		{"update only", "UPDATE ONLY users SET name = 'x'", "UPDATE", "users"},
+		{"sqlite update or", "UPDATE OR IGNORE users SET name = 'x'", "UPDATE", "users"},
+		{"delete", "DELETE FROM sessions WHERE expires_at < now()", "DELETE", "sessions"},
+		{"mysql delete", "DELETE LOW_PRIORITY FROM sessions", "DELETE", "sessions"},
+		{"merge", "MERGE INTO stock s USING deliveries d ON s.id = d.id", "MERGE", "stock"},
+		{"create table", "CREATE TABLE IF NOT EXISTS audit (id int)", "CREATE", "audit"},
+		{"drop table", "DROP TABLE IF EXISTS audit", "DROP", "audit"},
+		{"create index", "CREATE INDEX idx ON audit (id)", "CREATE", ""},
+		{"truncate", "TRUNCATE TABLE audit", "TRUNCATE", "audit"},
+		{"other verb", "begin", "BEGIN", ""},
+		{"cte select", "WITH recent AS (SELECT * FROM orders WHERE ts > $1) SELECT * FROM recent", "SELECT", ""},
+		{"cte insert", "WITH RECURSIVE t(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM t), u AS MATERIALIZED (SELECT 2) INSERT INTO numbers SELECT n FROM t", "INSERT", "numbers"},
+		{"cte delete", "WITH old AS (SELECT id FROM users) DELETE FROM sessions WHERE user_id IN (SELECT id FROM old)", "DELETE", "sessions"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operation, table := metricWrapper.ParseSQL(tt.query)
+			require.Equal(t, tt.operation, operation, "operation")
+			require.Equal(t, tt.table, table, "table")
+		})
+	}
+}
+
+func TestParseMySQL(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		operation string
+		table     string
+	}{
+		{"hash comment", "# FROM x\nSELECT * FROM users", "SELECT", "users"},
+		{"trailing hash comment", "SELECT * FROM users # FROM x", "SELECT", "users"},
+		{"backslash escape", `SELECT 'it\'s FROM fake' FROM real`, "SELECT", "real"},
+		{"backticks", "INSERT INTO `shop`.`orders` (id) VALUES (?)", "INSERT", "shop.orders"},
+		{"upsert", "INSERT INTO users (id) VALUES (1) ON DUPLICATE KEY UPDATE name = VALUES(name)", "UPSERT", "users"},
+		{"double-quoted string", `SELECT "it\"s FROM fake" FROM real`, "SELECT", "real"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operation, table := metricWrapper.ParseMySQL(tt.query)
+			require.Equal(t, tt.operation, operation, "operation")
+			require.Equal(t, tt.table, table, "table")
+		})
+	}
+}
+
+func TestParsePostgres(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		operation string
+		table     string
+	}{
+		{"array subscript", "SELECT a[1] FROM t", "SELECT", "t"},
+		{"subscript with bracket", "SELECT data['a]'] FROM docs", "SELECT", "docs"},
+		{"quoted identifier", `UPDATE "Shop"."Orders" SET total = $1`, "UPDATE", "Shop.Orders"},
+		{"escape string", `SELECT E'it\'s FROM fake' FROM real`, "SELECT", "real"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operation, table := metricWrapper.ParsePostgres(tt.query)
+			require.Equal(t, tt.operation, operation, "operation")
+			require.Equal(t, tt.table, table, "table")
+		})
+	}
+}
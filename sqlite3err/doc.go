@@ -0,0 +1,10 @@
+// Package sqlite3err classifies the errors of github.com/mattn/go-sqlite3 by
+// extended result code. Importing it registers the classifier under
+// ClassifierName:
+//
+//	import _ "github.com/janduursma/otel-metrics-wrapper-go/sqlite3err"
+//
+// The errors of drivers with a Code method, such as modernc.org/sqlite, are
+// classified without it. The package is empty when cgo is disabled, as the
+// driver requires cgo.
+package sqlite3err
@@ -0,0 +1,31 @@
+//go:build cgo
+
+package sqlite3err
+
+import (
+	"errors"
+
+	metrics "github.com/janduursma/otel-metrics-wrapper-go"
+	"github.com/mattn/go-sqlite3"
+)
+
+// ClassifierName is the name the classifier is registered under. It runs next
+// to the built-in classifier registered under metrics.ClassifierSQLite.
+const ClassifierName = "sqlite3"
+
+func init() {
+	metrics.RegisterErrorClassifier(ClassifierName, metrics.ErrorClassifierFunc(ClassifyError), 200)
+}
+
+// ClassifyError returns the database error category of the first sqlite3.Error
+// in the chain of err, or "" if there is none.
+func ClassifyError(err error) string {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return ""
+	}
+	if sqliteErr.ExtendedCode != 0 {
+		return metrics.SQLiteErrorType(int(sqliteErr.ExtendedCode))
+	}
+	return metrics.SQLiteErrorType(int(sqliteErr.Code))
+}
@@ -0,0 +1,48 @@
+//go:build cgo
+
+package sqlite3err_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/janduursma/otel-metrics-wrapper-go/sqlite3err"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "unique constraint",
+			err:      sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintUnique},
+			expected: "db_unique_violation",
+		},
+		{
+			name:     "wrapped busy",
+			err:      fmt.Errorf("exec: %w", sqlite3.Error{Code: sqlite3.ErrBusy}),
+			expected: "db_lock_timeout",
+		},
+		{
+			name:     "other error",
+			err:      sqlite3.Error{Code: sqlite3.ErrError},
+			expected: "db_error",
+		},
+		{
+			name:     "not a sqlite error",
+			err:      errors.New("boom"),
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, sqlite3err.ClassifyError(tt.err))
+		})
+	}
+}
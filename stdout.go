@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// createStdoutExporter creates an exporter that writes every collection as
+// JSON to StdoutFile, StdoutWriter or os.Stdout, in that order of preference.
+// Without StdoutPretty, each collection is written as a single line.
+func createStdoutExporter(cfg Config) (sdkmetric.Exporter, error) {
+	var (
+		w    = cfg.StdoutWriter
+		file *os.File
+	)
+	if cfg.StdoutFile != "" {
+		var err error
+		file, err = os.OpenFile(cfg.StdoutFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		w = file
+	}
+	if w == nil {
+		w = os.Stdout
+	}
+
+	opts := []stdoutmetric.Option{stdoutmetric.WithWriter(w)}
+	if cfg.StdoutPretty {
+		opts = append(opts, stdoutmetric.WithPrettyPrint())
+	}
+	exp, err := stdoutmetric.New(opts...)
+	if err != nil {
+		if file != nil {
+			_ = file.Close()
+		}
+		return nil, err
+	}
+
+	if file != nil {
+		return &closingExporter{Exporter: exp, closer: file}, nil
+	}
+	return exp, nil
+}
+
+// closingExporter closes the underlying file when the exporter is shut down.
+type closingExporter struct {
+	sdkmetric.Exporter
+	closer io.Closer
+}
+
+// Shutdown shuts down the wrapped exporter and then closes the file.
+func (c *closingExporter) Shutdown(ctx context.Context) error {
+	return errors.Join(c.Exporter.Shutdown(ctx), c.closer.Close())
+}
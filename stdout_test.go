@@ -0,0 +1,139 @@
+package metrics_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	metricWrapper "github.com/janduursma/otel-metrics-wrapper-go"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.String()
+}
+
+func TestStdoutExporter_Writer(t *testing.T) {
+	// Reset global state so that nothing is initialized.
+	metricWrapper.ResetState()
+
+	ctx := context.Background()
+	var out syncBuffer
+
+	// No OTLP endpoint is needed for the stdout exporter.
+	cfg := metricWrapper.NewConfig(
+		"",
+		"test-service",
+		"test",
+		metricWrapper.WithExporter(metricWrapper.ExporterStdout),
+		metricWrapper.WithStdoutWriter(&out),
+		metricWrapper.WithPushInterval(time.Hour),
+	)
+	err := metricWrapper.InitMetrics(ctx, cfg)
+	require.NoError(t, err, "expected no error during InitMetrics")
+
+	hm, err := metricWrapper.NewHTTPMetrics(metricWrapper.GetMeter("test-meter"))
+	require.NoError(t, err)
+	hm.RecordRequestStart(ctx, "GET", "/users")
+	hm.RecordRequestEnd(ctx, "GET", "/users", 200, 42, time.Now())
+
+	// Shutdown flushes the final collection to the writer.
+	err = metricWrapper.ShutdownMetrics(ctx)
+	require.NoError(t, err, "expected no error during ShutdownMetrics")
+
+	require.Contains(t, out.String(), `"requests.total"`)
+	require.Contains(t, out.String(), `"test-service"`)
+}
+
+func TestStdoutExporter_FileJSONLines(t *testing.T) {
+	// Reset global state so that nothing is initialized.
+	metricWrapper.ResetState()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "metrics.jsonl")
+
+	cfg := metricWrapper.NewConfig(
+		"",
+		"test-service",
+		"test",
+		metricWrapper.WithExporter(metricWrapper.ExporterStdout),
+		metricWrapper.WithStdoutFile(path),
+		metricWrapper.WithPushInterval(time.Hour),
+	)
+	err := metricWrapper.InitMetrics(ctx, cfg)
+	require.NoError(t, err, "expected no error during InitMetrics")
+
+	dbm, err := metricWrapper.NewDBMetrics(metricWrapper.GetMeter("test-meter"))
+	require.NoError(t, err)
+	dbm.RecordDBCall(ctx, "postgres", "SELECT", "users")
+	dbm.FinishDBCall(ctx, "postgres", "SELECT", "users", nil, time.Now())
+
+	err = metricWrapper.ShutdownMetrics(ctx)
+	require.NoError(t, err, "expected no error during ShutdownMetrics")
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	// Every line is a standalone JSON document.
+	lines := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var doc map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &doc))
+		require.Contains(t, scanner.Text(), `"db.calls.total"`)
+		lines++
+	}
+	require.NoError(t, scanner.Err())
+	require.Equal(t, 1, lines)
+}
+
+func TestStdoutExporter_InvalidConfig(t *testing.T) {
+	// Reset global state so that nothing is initialized.
+	metricWrapper.ResetState()
+
+	cfg := metricWrapper.NewConfig(
+		"",
+		"test-service",
+		"test",
+		metricWrapper.WithExporter(metricWrapper.ExporterStdout),
+		metricWrapper.WithStdoutWriter(os.Stderr),
+		metricWrapper.WithStdoutFile("metrics.jsonl"),
+	)
+	err := metricWrapper.InitMetrics(context.Background(), cfg)
+	require.ErrorContains(t, err, "mutually exclusive")
+
+	// Reset global state so that nothing is initialized.
+	metricWrapper.ResetState()
+
+	cfg = metricWrapper.NewConfig(
+		"",
+		"test-service",
+		"test",
+		metricWrapper.WithExporter(metricWrapper.ExporterStdout),
+		metricWrapper.WithStdoutFile(filepath.Join(t.TempDir(), "missing", "metrics.jsonl")),
+	)
+	err = metricWrapper.InitMetrics(context.Background(), cfg)
+	require.ErrorContains(t, err, "failed to create stdout exporter")
+}
@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// buildTLSConfig assembles the TLS configuration for secure OTLP connections.
+// A user-supplied TLSConfig is used as the base and is never modified; the CA
+// file, server name and client certificate options are layered on top of it.
+func buildTLSConfig(cfg Config) (*tls.Config, error) {
+	var tlsCfg *tls.Config
+	if cfg.TLSConfig != nil {
+		tlsCfg = cfg.TLSConfig.Clone()
+	} else {
+		tlsCfg = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	if cfg.OTLPCAFile != "" {
+		pool, err := loadCertPool(cfg.OTLPCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load CA file: %w", err)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if cfg.OTLPServerName != "" {
+		tlsCfg.ServerName = cfg.OTLPServerName
+	}
+
+	if cfg.OTLPClientCertFile != "" || cfg.OTLPClientKeyFile != "" {
+		reloader, err := newCertReloader(cfg.OTLPClientCertFile, cfg.OTLPClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsCfg.Certificates = nil
+		tlsCfg.GetClientCertificate = reloader.GetClientCertificate
+	}
+
+	return tlsCfg, nil
+}
+
+// certReloader serves a client certificate from disk and reloads it whenever
+// the certificate or key file changes, so rotated credentials are picked up
+// on the next TLS handshake without restarting the process.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu          sync.Mutex
+	cert        *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+}
+
+// newCertReloader loads the initial key pair and returns a reloader for it.
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetClientCertificate implements tls.Config.GetClientCertificate.
+// If reloading a changed key pair fails, the previous certificate is kept.
+func (r *certReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.changed() {
+		if err := r.reload(); err != nil {
+			log.Printf("[metrics] Failed to reload client certificate, keeping previous: %v", err)
+		}
+	}
+	return r.cert, nil
+}
+
+// changed reports whether either file has been modified since the last load.
+func (r *certReloader) changed() bool {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return false
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return false
+	}
+	return !certInfo.ModTime().Equal(r.certModTime) || !keyInfo.ModTime().Equal(r.keyModTime)
+}
+
+// reload reads the key pair from disk, failing if the key does not match the certificate.
+func (r *certReloader) reload() error {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return err
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert = &cert
+	r.certModTime = certInfo.ModTime()
+	r.keyModTime = keyInfo.ModTime()
+	return nil
+}
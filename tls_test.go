@@ -0,0 +1,156 @@
+package metrics
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeKeyPair generates a self-signed certificate with the given common name
+// and writes it and its private key as PEM files into dir.
+func writeKeyPair(t *testing.T, dir, name string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+// leafCommonName returns the subject common name of the given certificate.
+func leafCommonName(t *testing.T, cert *tls.Certificate) string {
+	t.Helper()
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	caFile, _ := writeKeyPair(t, dir, "ca")
+	certFile, keyFile := writeKeyPair(t, dir, "client")
+
+	base := &tls.Config{MinVersion: tls.VersionTLS13}
+	cfg := NewConfig("localhost:4317", "test-service", "test",
+		WithOTLPInsecure(false),
+		WithOTLPCAFile(caFile),
+		WithOTLPServerName("collector.internal"),
+		WithOTLPClientCert(certFile, keyFile),
+		WithTLSConfig(base),
+	)
+
+	tlsCfg, err := buildTLSConfig(cfg)
+	require.NoError(t, err)
+	require.NotSame(t, base, tlsCfg, "the supplied TLS config must not be modified")
+	require.Equal(t, uint16(tls.VersionTLS13), tlsCfg.MinVersion)
+	require.Equal(t, "collector.internal", tlsCfg.ServerName)
+	require.NotNil(t, tlsCfg.RootCAs)
+	require.Empty(t, base.ServerName)
+
+	cert, err := tlsCfg.GetClientCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, "client", leafCommonName(t, cert))
+}
+
+func TestBuildTLSConfig_MismatchedKeyPair(t *testing.T) {
+	dir := t.TempDir()
+	certFile, _ := writeKeyPair(t, dir, "client")
+	_, otherKey := writeKeyPair(t, dir, "other")
+
+	cfg := NewConfig("localhost:4317", "test-service", "test",
+		WithOTLPInsecure(false),
+		WithTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12}),
+		WithOTLPClientCert(certFile, otherKey),
+	)
+	_, err := buildTLSConfig(cfg)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to load client certificate")
+}
+
+func TestCertReloader_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeKeyPair(t, dir, "client")
+
+	r, err := newCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+
+	cert, err := r.GetClientCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, "client", leafCommonName(t, cert))
+
+	// Rotate the key pair in place and bump the modification time.
+	rotatedCert, rotatedKey := writeKeyPair(t, t.TempDir(), "rotated")
+	for src, dst := range map[string]string{rotatedCert: certFile, rotatedKey: keyFile} {
+		data, err := os.ReadFile(src)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(dst, data, 0o600))
+		future := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(dst, future, future))
+	}
+
+	cert, err = r.GetClientCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, "rotated", leafCommonName(t, cert))
+
+	// A broken rotation keeps serving the last good certificate.
+	require.NoError(t, os.WriteFile(keyFile, []byte("garbage"), 0o600))
+	past := time.Now().Add(-time.Minute)
+	require.NoError(t, os.Chtimes(keyFile, past, past))
+
+	cert, err = r.GetClientCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, "rotated", leafCommonName(t, cert))
+}
+
+func TestValidateConfig_TLS(t *testing.T) {
+	// Only one half of the key pair.
+	cfg := NewConfig("localhost:4317", "test-service", "test",
+		WithOTLPInsecure(false),
+		WithOTLPCAFile("ca.pem"),
+		WithOTLPClientCert("client.crt", ""),
+	)
+	require.ErrorContains(t, validateConfig(cfg), "must be set together")
+
+	// Client certificates in insecure mode.
+	cfg = NewConfig("localhost:4317", "test-service", "test",
+		WithOTLPClientCert("client.crt", "client.key"),
+	)
+	require.ErrorContains(t, validateConfig(cfg), "requires secure mode")
+
+	// A TLS config replaces the need for a CA file.
+	cfg = NewConfig("localhost:4317", "test-service", "test",
+		WithOTLPInsecure(false),
+		WithTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12}),
+	)
+	require.NoError(t, validateConfig(cfg))
+}
@@ -0,0 +1,197 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// TransportOption configures Transport.
+type TransportOption func(*transportConfig)
+
+// transportConfig holds the settings of Transport.
+type transportConfig struct {
+	targetService func(r *http.Request) string
+}
+
+// WithTargetServiceMapper sets the function used by Transport to derive the
+// target_service attribute from an outbound request, e.g. to map hosts to
+// logical service names. If it returns an empty string, the host is used.
+func WithTargetServiceMapper(mapper func(r *http.Request) string) TransportOption {
+	return func(cfg *transportConfig) {
+		cfg.targetService = mapper
+	}
+}
+
+// resolveTargetService determines the target service of r: from the configured
+// mapper, and otherwise from the host name of the request URL.
+func (cfg transportConfig) resolveTargetService(r *http.Request) string {
+	if cfg.targetService != nil {
+		if target := cfg.targetService(r); target != "" {
+			return target
+		}
+	}
+	if host := r.URL.Hostname(); host != "" {
+		return host
+	}
+	return r.Host
+}
+
+// Transport wraps base so that every outbound request is recorded through
+// RecordExternalCall, together with its latency, status code and request and
+// response body sizes. If base is nil, http.DefaultTransport is used.
+//
+// Transport errors are counted in CallsErrors with an error_type attribute
+// derived from the error, and responses with a 5xx status code with the
+// error_type "http_server_error", as the service failed to handle the request.
+// 4xx responses are not counted as errors, since they reflect the request
+// rather than the health of the service; they remain visible through the
+// status_code attribute of CallsLatency.
+//
+// The latency covers the time until the response headers are received. The
+// request and response sizes are recorded once their body has been read to EOF
+// or closed; the request size has no status_code attribute, as a body may
+// still be sent after the response has been received.
+func (em *ExternalMetrics) Transport(base http.RoundTripper, opts ...TransportOption) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	var cfg transportConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &transport{em: em, base: base, cfg: cfg}
+}
+
+// transport is the http.RoundTripper returned by ExternalMetrics.Transport.
+type transport struct {
+	em   *ExternalMetrics
+	base http.RoundTripper
+	cfg  transportConfig
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	ctx := req.Context()
+	target := t.cfg.resolveTargetService(req)
+
+	t.em.RecordExternalCall(ctx, target, req.Method)
+
+	// The request size is recorded once the body has been sent, which may be
+	// after the response headers have been received. A body of unknown length
+	// is counted while it is read; the request is copied, as a RoundTripper
+	// must not modify it.
+	reqAttrs := metric.WithAttributes(
+		attribute.String("target_service", target),
+		attribute.String("method", req.Method),
+	)
+	if req.Body != nil && req.Body != http.NoBody && req.ContentLength <= 0 {
+		r := new(http.Request)
+		*r = *req
+		r.Body = &reportingBody{
+			countingReadCloser: countingReadCloser{ReadCloser: req.Body},
+			done: func(n int64) {
+				t.em.RequestSize.Record(context.WithoutCancel(ctx), n, reqAttrs)
+			},
+		}
+		req = r
+	} else {
+		t.em.RequestSize.Record(ctx, max(req.ContentLength, 0), reqAttrs)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.em.FinishExternalCall(ctx, target, req.Method, err, start)
+		return nil, err
+	}
+
+	var errorType string
+	if resp.StatusCode >= http.StatusInternalServerError {
+		errorType = httpErrServer
+	}
+	statusCode := attribute.Int("status_code", resp.StatusCode)
+	t.em.finishCall(ctx, target, req.Method, errorType, start, statusCode)
+
+	// Bodies of protocol upgrades are writable connections and are left as is.
+	if _, ok := resp.Body.(io.Writer); ok || resp.Body == nil {
+		return resp, nil
+	}
+	respAttrs := metric.WithAttributes(
+		attribute.String("target_service", target),
+		attribute.String("method", req.Method),
+		statusCode,
+	)
+	resp.Body = &reportingBody{
+		countingReadCloser: countingReadCloser{ReadCloser: resp.Body},
+		done: func(n int64) {
+			t.em.ResponseSize.Record(context.WithoutCancel(ctx), n, respAttrs)
+		},
+	}
+	return resp, nil
+}
+
+// CloseIdleConnections closes the idle connections of the underlying
+// RoundTripper, if it supports it, so that http.Client.CloseIdleConnections
+// works on an instrumented client.
+func (t *transport) CloseIdleConnections() {
+	if c, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+// countingReadCloser counts the bytes read through an io.ReadCloser.
+type countingReadCloser struct {
+	io.ReadCloser
+	mu sync.Mutex
+	n  int64
+}
+
+// Read reads from the underlying reader and counts the bytes read.
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.mu.Lock()
+	c.n += int64(n)
+	c.mu.Unlock()
+	return n, err
+}
+
+// bytes returns the number of bytes read so far.
+func (c *countingReadCloser) bytes() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.n
+}
+
+// reportingBody counts the bytes read from a request or response body and
+// reports the total once, when the body reaches EOF or is closed.
+type reportingBody struct {
+	countingReadCloser
+	once sync.Once
+	done func(n int64)
+}
+
+// Read reads from the body, reporting the total size on EOF.
+func (b *reportingBody) Read(p []byte) (int, error) {
+	n, err := b.countingReadCloser.Read(p)
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+// Close closes the body, reporting the total size if not done already.
+func (b *reportingBody) Close() error {
+	b.finish()
+	return b.countingReadCloser.Close()
+}
+
+// finish reports the number of bytes read, at most once.
+func (b *reportingBody) finish() {
+	b.once.Do(func() { b.done(b.bytes()) })
+}
@@ -0,0 +1,191 @@
+package metrics_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	metricWrapper "github.com/janduursma/otel-metrics-wrapper-go"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// newTestExternalMetrics returns ExternalMetrics backed by a ManualReader.
+func newTestExternalMetrics(t *testing.T) (*metricWrapper.ExternalMetrics, *sdkMetric.ManualReader) {
+	t.Helper()
+
+	reader := sdkMetric.NewManualReader()
+	mp := sdkMetric.NewMeterProvider(sdkMetric.WithReader(reader))
+	em, err := metricWrapper.NewExternalMetrics(mp.Meter("test-meter"))
+	require.NoError(t, err, "failed to create ExternalMetrics")
+	return em, reader
+}
+
+func TestTransport_Success(t *testing.T) {
+	em, reader := newTestExternalMetrics(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("created"))
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: em.Transport(nil)}
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("hello"))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, "created", string(body))
+
+	rm := collect(t, reader)
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "external.calls.total", attribute.String("target_service", "127.0.0.1")))
+	require.EqualValues(t, 1, findHistogramCountByName(t, rm, "external.calls.duration"))
+	require.EqualValues(t, len("hello"), findHistogramSumByName(t, rm, "external.request.size"))
+	require.EqualValues(t, len("created"), findHistogramSumByName(t, rm, "external.response.size"))
+}
+
+func TestTransport_UnknownRequestLength(t *testing.T) {
+	em, reader := newTestExternalMetrics(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+	}))
+	defer srv.Close()
+
+	// A body of unknown length is counted while it is sent.
+	req, err := http.NewRequest(http.MethodPut, srv.URL, io.NopCloser(strings.NewReader("streamed body")))
+	require.NoError(t, err)
+	resp, err := em.Transport(http.DefaultTransport).RoundTrip(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	rm := collect(t, reader)
+	require.EqualValues(t, len("streamed body"), findHistogramSumByName(t, rm, "external.request.size"))
+	require.EqualValues(t, 1, findHistogramCountByName(t, rm, "external.response.size"))
+}
+
+func TestTransport_TargetServiceMapper(t *testing.T) {
+	em, reader := newTestExternalMetrics(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	rt := em.Transport(nil, metricWrapper.WithTargetServiceMapper(func(*http.Request) string {
+		return "billing-api"
+	}))
+	resp, err := (&http.Client{Transport: rt}).Get(srv.URL)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	rm := collect(t, reader)
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "external.calls.total", attribute.String("target_service", "billing-api")))
+	require.EqualValues(t, 1, findHistogramCountByName(t, rm, "external.response.size"))
+}
+
+func TestTransport_StatusErrors(t *testing.T) {
+	em, reader := newTestExternalMetrics(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: em.Transport(nil)}
+	for _, path := range []string{"/missing", "/upstream"} {
+		resp, err := client.Get(srv.URL + path)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	// Only the 5xx response is an error.
+	rm := collect(t, reader)
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "external.calls.errors", attribute.String("error_type", "http_server_error")))
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "external.calls.errors", attribute.Int("status_code", http.StatusBadGateway)))
+	require.EqualValues(t, 2, findHistogramCountByName(t, rm, "external.calls.duration"))
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestTransport_EarlyResponse(t *testing.T) {
+	em, reader := newTestExternalMetrics(t)
+
+	// The response arrives before the request body has been sent.
+	sent := make(chan struct{})
+	rt := em.Transport(roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		go func() {
+			defer close(sent)
+			_, _ = io.Copy(io.Discard, r.Body)
+			_ = r.Body.Close()
+		}()
+		return &http.Response{StatusCode: http.StatusAccepted, Body: http.NoBody}, nil
+	}))
+
+	body, w := io.Pipe()
+	req, err := http.NewRequest(http.MethodPost, "http://upload.internal/", body)
+	require.NoError(t, err)
+	resp, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	_, _ = w.Write([]byte("late body"))
+	require.NoError(t, w.Close())
+	<-sent
+
+	rm := collect(t, reader)
+	require.EqualValues(t, len("late body"), findHistogramSumByName(t, rm, "external.request.size"))
+}
+
+// idleCloser is an http.RoundTripper that counts calls to CloseIdleConnections.
+type idleCloser struct {
+	http.RoundTripper
+	closed int
+}
+
+func (c *idleCloser) CloseIdleConnections() {
+	c.closed++
+}
+
+func TestTransport_CloseIdleConnections(t *testing.T) {
+	em, _ := newTestExternalMetrics(t)
+
+	base := &idleCloser{RoundTripper: http.DefaultTransport}
+	client := &http.Client{Transport: em.Transport(base)}
+	client.CloseIdleConnections()
+	require.Equal(t, 1, base.closed)
+}
+
+func TestTransport_Error(t *testing.T) {
+	em, reader := newTestExternalMetrics(t)
+
+	rt := em.Transport(roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}))
+	req, err := http.NewRequest(http.MethodGet, "http://payments.internal/charge", nil)
+	require.NoError(t, err)
+
+	_, err = rt.RoundTrip(req)
+	require.Error(t, err)
+
+	rm := collect(t, reader)
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "external.calls.total", attribute.String("target_service", "payments.internal")))
+	require.EqualValues(t, 1, findIntSumByAttr(t, rm, "external.calls.errors", attribute.String("error_type", "unknown")))
+	require.EqualValues(t, 1, findHistogramCountByName(t, rm, "external.calls.duration"))
+}